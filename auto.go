@@ -0,0 +1,48 @@
+package zinject
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// AutoRegisterFlags control how AutoRegister maps the fields of a struct.
+type AutoRegisterFlags int
+
+const (
+	// SkipZero leaves the fields holding their zero value unmapped.
+	SkipZero AutoRegisterFlags = 1 << iota
+	// DynamicType maps interface fields under the dynamic type of their
+	// value rather than under the interface type, nil ones being skipped.
+	DynamicType
+)
+
+func (inj *injector) AutoRegister(val interface{}, key string, flags AutoRegisterFlags) Injector {
+	v := reflect.ValueOf(val)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("Called inject.AutoRegister with a value that is not a struct: %v", reflect.TypeOf(val)))
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		f := v.Field(i)
+		if flags&SkipZero != 0 && f.IsZero() {
+			continue
+		}
+		typ := f.Type()
+		if flags&DynamicType != 0 && f.Kind() == reflect.Interface {
+			if f.IsNil() {
+				continue
+			}
+			f = f.Elem()
+			typ = f.Type()
+		}
+		inj.Set(typ, key, f)
+	}
+	return inj
+}
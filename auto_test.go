@@ -0,0 +1,43 @@
+package zinject_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+type Dependencies struct {
+	DB       *Database
+	Name     string
+	Port     int
+	Stringer fmt.Stringer
+	Nothing  fmt.Stringer
+	private  string
+}
+
+func Test_InjectorAutoRegister(t *testing.T) {
+	deps := &Dependencies{DB: &Database{DSN: "dsn"}, Name: "app", Stringer: &Greeter{"Jeremy"}, private: "hidden"}
+	stringer := zinject.InterfaceOf((*fmt.Stringer)(nil))
+
+	injector := zinject.New()
+	injector.AutoRegister(deps, "", 0)
+	expect(t, len(injector.Registrations()), 4)
+	expect(t, injector.Get(reflect.TypeOf(&Database{}), "").Interface(), deps.DB)
+	expect(t, injector.Get(reflect.TypeOf(""), "").String(), "app")
+	expect(t, injector.Get(reflect.TypeOf(0), "").Int(), int64(0))
+	expect(t, injector.HasLocal(stringer, ""), true)
+
+	injector = zinject.New()
+	injector.AutoRegister(*deps, "deps", zinject.SkipZero|zinject.DynamicType)
+	expect(t, len(injector.Registrations()), 3)
+	expect(t, injector.Has(reflect.TypeOf(0), "deps"), false)
+	expect(t, injector.HasLocal(stringer, "deps"), false)
+	expect(t, injector.Get(reflect.TypeOf(&Greeter{}), "deps").Interface(), deps.Stringer)
+
+	defer func() {
+		expect(t, fmt.Sprint(recover()), "Called inject.AutoRegister with a value that is not a struct: int")
+	}()
+	injector.AutoRegister(1, "", 0)
+}
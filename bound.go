@@ -0,0 +1,114 @@
+package zinject
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// bound is the Injector handed to the providers and functions resolving the
+// Injector type while a resolution is in progress. Its lookups, injections
+// and invocations continue that resolution, so that a provider requesting
+// its own type fails with a *CycleError instead of waiting for itself or
+// recursing endlessly. The other methods are those of the injector.
+type bound struct {
+	*injector
+	// stack holds the dependencies being resolved when it was created.
+	stack []dependency
+	dry   bool
+}
+
+// bind returns the injector bound to the dependencies of stack, or the
+// injector itself if stack is empty.
+func (inj *injector) bind(stack []dependency, dry bool) Injector {
+	if len(stack) == 0 && !dry {
+		return inj
+	}
+	return &bound{injector: inj, stack: append([]dependency(nil), stack...), dry: dry}
+}
+
+// resolution returns a new resolution continuing the one b is bound to.
+func (b *bound) resolution() *resolution {
+	return &resolution{stack: append([]dependency(nil), b.stack...), dry: b.dry, origin: b.injector}
+}
+
+func (b *bound) Get(t reflect.Type, key string) reflect.Value {
+	val, _ := b.get(t, key, b.resolution())
+	return val
+}
+
+func (b *bound) GetE(t reflect.Type, key string) (reflect.Value, error) {
+	val, err := b.get(t, key, b.resolution())
+	if err == nil && !val.IsValid() {
+		err = &NotFoundError{Type: t, Key: b.keyOf(key)}
+	}
+	return val, err
+}
+
+func (b *bound) MustGet(t reflect.Type, key string) reflect.Value {
+	val := b.Get(t, key)
+	if !val.IsValid() {
+		panic(fmt.Sprintf("Value not found for type %v with key %q", t, key))
+	}
+	return val
+}
+
+func (b *bound) GetAndInject(t reflect.Type, key string) (reflect.Value, error) {
+	return b.getAndInject(t, key, b.resolution())
+}
+
+func (b *bound) ResolveAll(t reflect.Type) []reflect.Value {
+	return b.resolveAll(t, b.resolution())
+}
+
+func (b *bound) Inject(val interface{}) error {
+	return b.inject(val, &injection{base: b.resolution()})
+}
+
+func (b *bound) InjectDeep(val interface{}) error {
+	return b.inject(val, &injection{deep: true, base: b.resolution()})
+}
+
+func (b *bound) InjectUnexported(val interface{}) error {
+	return b.inject(val, &injection{unexported: true, base: b.resolution()})
+}
+
+func (b *bound) InjectByName(val interface{}) error {
+	return b.inject(val, &injection{byName: true, base: b.resolution()})
+}
+
+func (b *bound) InjectAll(vals ...interface{}) error {
+	return b.injectAll(vals, b.resolution())
+}
+
+func (b *bound) InjectAllErrors(val interface{}) error {
+	return b.injectCollecting(val, &injection{base: b.resolution()})
+}
+
+func (b *bound) CanInject(val interface{}) error {
+	return b.injectCollecting(val, &injection{dry: true, base: b.resolution()})
+}
+
+func (b *bound) InjectValue(val interface{}) (interface{}, error) {
+	return b.injectValue(val, b.resolution())
+}
+
+func (b *bound) Invoke(f interface{}) ([]reflect.Value, error) {
+	return b.invoke(f, b.resolution(), nil)
+}
+
+func (b *bound) InvokeCtx(ctx context.Context, f interface{}) ([]reflect.Value, error) {
+	return b.invokeCtx(ctx, f, b.resolution())
+}
+
+func (b *bound) InvokeWith(f interface{}, overrides ...interface{}) ([]reflect.Value, error) {
+	return b.invokeWith(f, overrides, b.resolution())
+}
+
+func (b *bound) InvokeE(f interface{}) (interface{}, error) {
+	return b.invokeE(f, b.resolution())
+}
+
+func (b *bound) RegisterFunc(fn interface{}, key string) error {
+	return b.registerFunc(fn, key, b.resolution())
+}
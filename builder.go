@@ -0,0 +1,49 @@
+package zinject
+
+// Builder declares the mappings of an Injector, built once they are all
+// declared. The zero value is an empty Builder ready to use.
+//
+//	inj, err := new(zinject.Builder).
+//		Provide("localhost:5432", "dsn").
+//		ProvideAs(os.Stdout, "log", (*io.Writer)(nil)).
+//		Constructor(NewRepository, "").
+//		Build()
+type Builder struct {
+	steps []func(Injector)
+}
+
+// Provide declares a mapping of the type of val to val, see Register.
+func (b *Builder) Provide(val interface{}, key string) *Builder {
+	b.steps = append(b.steps, func(inj Injector) { inj.Register(val, key) })
+	return b
+}
+
+// ProvideAs declares a mapping of the interface type ifacePtr points to to
+// val, see RegisterAs.
+func (b *Builder) ProvideAs(val interface{}, key string, ifacePtr interface{}) *Builder {
+	b.steps = append(b.steps, func(inj Injector) { inj.RegisterAs(val, key, ifacePtr) })
+	return b
+}
+
+// Constructor declares a constructor provider, see ProvideConstructor.
+func (b *Builder) Constructor(fn interface{}, key string) *Builder {
+	b.steps = append(b.steps, func(inj Injector) { inj.ProvideConstructor(fn, key) })
+	return b
+}
+
+// Build returns a new frozen Injector holding the declared mappings, or the
+// error returned by Validate if the dependencies of a constructor cannot be
+// resolved. The declarations are applied in order, panicking like the
+// methods they stand for. Build can be called several times, each call
+// returning a distinct Injector.
+func (b *Builder) Build() (Injector, error) {
+	inj := New()
+	for _, step := range b.steps {
+		step(inj)
+	}
+	if err := inj.Validate(); err != nil {
+		return nil, err
+	}
+	inj.Freeze()
+	return inj, nil
+}
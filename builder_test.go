@@ -0,0 +1,31 @@
+package zinject_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_Builder(t *testing.T) {
+	b := new(zinject.Builder).
+		Provide("Jeremy", "name").
+		ProvideAs(&Farewell{"Jeremy"}, "bye", (*fmt.Stringer)(nil)).
+		Constructor(func(n int) *Greeter { return &Greeter{fmt.Sprint("Jeremy ", n)} }, "")
+
+	_, err := b.Build()
+	refute(t, err, nil)
+
+	injector, err := b.Provide(11, "").Build()
+	expect(t, err, nil)
+	expect(t, injector.Get(reflect.TypeOf(""), "name").String(), "Jeremy")
+	expect(t, injector.Get(zinject.InterfaceOf((*fmt.Stringer)(nil)), "bye").Interface().(fmt.Stringer).String(), "Goodbye, Jeremy")
+	expect(t, injector.Get(reflect.TypeOf(&Greeter{}), "").Interface().(*Greeter).Name, "Jeremy 11")
+	expectFrozen(t, func() { injector.Register("another dep", "") })
+
+	// each build returns a distinct injector
+	other, err := b.Build()
+	expect(t, err, nil)
+	refute(t, other, injector)
+}
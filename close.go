@@ -0,0 +1,45 @@
+package zinject
+
+import (
+	"errors"
+	"io"
+	"reflect"
+)
+
+// Closes every value registered directly on the injector that implements
+// io.Closer, in reverse registration order, so that dependents are closed
+// before their dependencies. Values not created yet by their provider,
+// values of the parents and nil values are left alone. A value registered
+// under several keys is closed once. Returns the errors of every failed
+// Close joined.
+func (inj *injector) Close() error {
+	inj.mu.RLock()
+	values := make([]reflect.Value, 0, len(inj.order))
+	for _, reg := range inj.order {
+		values = append(values, inj.values[reg.Type][reg.Key])
+	}
+	inj.mu.RUnlock()
+
+	var errs []error
+	closed := map[interface{}]bool{}
+	for i := len(values) - 1; i >= 0; i-- {
+		v := values[i]
+		if !v.IsValid() || !v.CanInterface() || nilable(v.Type()) && v.IsNil() {
+			continue
+		}
+		c, ok := v.Interface().(io.Closer)
+		if !ok || c == nil {
+			continue
+		}
+		if reflect.ValueOf(c).Comparable() {
+			if closed[c] {
+				continue
+			}
+			closed[c] = true
+		}
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,69 @@
+package zinject_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+type Closer struct {
+	Name   string
+	Err    error
+	Closed *[]string
+}
+
+func (c *Closer) Close() error {
+	*c.Closed = append(*c.Closed, c.Name)
+	return c.Err
+}
+
+func Test_InjectorClose(t *testing.T) {
+	var closed []string
+	errPool := errors.New("pool busy")
+	errFile := errors.New("file busy")
+
+	parent := zinject.New()
+	parent.Register(&Closer{Name: "parent", Closed: &closed}, "")
+
+	injector := parent.Child()
+	pool := &Closer{Name: "pool", Err: errPool, Closed: &closed}
+	injector.Register(pool, "pool").
+		Register("not a closer", "").
+		Register(&Closer{Name: "file", Err: errFile, Closed: &closed}, "file").
+		Register(&Closer{Name: "service", Closed: &closed}, "service").
+		Register(pool, "alias").
+		RegisterNil(reflect.TypeOf(&Closer{}), "nil")
+	injector.Factory(func() *Database { return &Database{} }, "")
+
+	err := injector.Close()
+	expect(t, errors.Is(err, errPool), true)
+	expect(t, errors.Is(err, errFile), true)
+	expect(t, len(closed), 3)
+	expect(t, closed[0], "pool")
+	expect(t, closed[1], "service")
+	expect(t, closed[2], "file")
+
+	expect(t, zinject.New().Close(), nil)
+}
+
+type ValueCloser struct {
+	Data   interface{}
+	Closed *[]string
+}
+
+func (c ValueCloser) Close() error {
+	*c.Closed = append(*c.Closed, "value")
+	return nil
+}
+
+func Test_InjectorCloseUncomparable(t *testing.T) {
+	var closed []string
+	injector := zinject.New()
+	injector.Register(ValueCloser{Data: []int{1}, Closed: &closed}, "").
+		Register(ValueCloser{Data: []int{1}, Closed: &closed}, "other")
+
+	expect(t, injector.Close(), nil)
+	expect(t, len(closed), 2)
+}
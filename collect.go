@@ -0,0 +1,121 @@
+package zinject
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// allKeys is the inject tag key collecting the values of every key.
+const allKeys = "*"
+
+// binding is a registration along with its Value.
+type binding struct {
+	Registration
+	val reflect.Value
+}
+
+// collect returns every Value whose type is assignable to t, in the injector
+// then in its parents, in registration order. Registrations of a parent that
+// are shadowed by the injector are skipped. Registrations whose provider
+// fails are left out, the first error being returned with the others. A nil
+// r starts a new resolution.
+func (inj *injector) collect(t reflect.Type, r *resolution) ([]binding, error) {
+	if r == nil {
+		r = &resolution{}
+	}
+	if r.origin == nil {
+		r.origin = inj
+	}
+
+	var bindings []binding
+	var first error
+	seen := map[Registration]bool{}
+	visited := map[*injector]bool{}
+	for cur := inj; cur != nil && !visited[cur]; cur, _ = cur.Parent().(*injector) {
+		visited[cur] = true
+		for _, reg := range cur.Registrations() {
+			if seen[reg] || !reg.Type.AssignableTo(t) {
+				continue
+			}
+			seen[reg] = true
+			if err := r.enter(cur, reg.Type, reg.Key); err != nil {
+				if first == nil {
+					first = err
+				}
+				continue
+			}
+			val, err := cur.lookup(reg.Type, reg.Key, r)
+			r.leave()
+			if err != nil && first == nil {
+				first = err
+			}
+			if err == nil && val.IsValid() {
+				bindings = append(bindings, binding{Registration: reg, val: val})
+			}
+		}
+	}
+	return bindings, first
+}
+
+func (inj *injector) ResolveAll(t reflect.Type) []reflect.Value {
+	return inj.resolveAll(t, nil)
+}
+
+func (inj *injector) resolveAll(t reflect.Type, r *resolution) []reflect.Value {
+	bindings, _ := inj.collect(t, r)
+	vals := make([]reflect.Value, len(bindings))
+	for i, b := range bindings {
+		vals[i] = b.val
+	}
+	return vals
+}
+
+// collectSlice returns a slice of type t holding every Value assignable to
+// its element type.
+func (inj *injector) collectSlice(t reflect.Type, r *resolution) (reflect.Value, error) {
+	bindings, err := inj.collect(t.Elem(), r)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	s := reflect.MakeSlice(t, 0, len(bindings))
+	for _, b := range bindings {
+		s = reflect.Append(s, b.val)
+	}
+	return s, nil
+}
+
+// collectArray returns an array of type t holding every Value assignable to
+// its element type, the remaining elements being left zeroed. Returns an
+// error if there are more values than the array can hold.
+func (inj *injector) collectArray(t reflect.Type, r *resolution) (reflect.Value, error) {
+	bindings, err := inj.collect(t.Elem(), r)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if len(bindings) > t.Len() {
+		return reflect.Value{}, fmt.Errorf("%d values of type %v exceed the length of %v", len(bindings), t.Elem(), t)
+	}
+	a := reflect.New(t).Elem()
+	for i, b := range bindings {
+		a.Index(i).Set(b.val)
+	}
+	return a, nil
+}
+
+// collectMap returns a map of type t holding every Value assignable to its
+// element type by registration key, the "" key included. When several types
+// are registered under the same key, the first registration wins.
+func (inj *injector) collectMap(t reflect.Type, r *resolution) (reflect.Value, error) {
+	bindings, err := inj.collect(t.Elem(), r)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	m := reflect.MakeMapWithSize(t, len(bindings))
+	for _, b := range bindings {
+		k := reflect.ValueOf(b.Key).Convert(t.Key())
+		if !m.MapIndex(k).IsValid() {
+			m.SetMapIndex(k, b.val)
+		}
+	}
+	return m, nil
+}
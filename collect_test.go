@@ -0,0 +1,127 @@
+package zinject_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+type CollectStruct struct {
+	Stringers []fmt.Stringer `inject:"*"`
+}
+
+func Test_InjectorCollectSlice(t *testing.T) {
+	parent := zinject.New()
+	parent.Register(&Greeter{"Parent"}, "").Register(&Farewell{"Parent"}, "parent")
+
+	injector := parent.Child()
+	injector.Register(&Farewell{"Jeremy"}, "").
+		Register("not a stringer", "").
+		Register(&Greeter{"Jeremy"}, "")
+
+	s := CollectStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, len(s.Stringers), 3)
+	expect(t, s.Stringers[0].String(), "Goodbye, Jeremy")
+	expect(t, s.Stringers[1].String(), "Hello, My name isJeremy")
+	expect(t, s.Stringers[2].String(), "Goodbye, Parent")
+
+	s = CollectStruct{}
+	err = zinject.New().Inject(&s)
+	expect(t, err, nil)
+	expect(t, len(s.Stringers), 0)
+}
+
+type CollectMapStruct struct {
+	Stringers map[string]fmt.Stringer `inject:"*"`
+}
+
+func Test_InjectorCollectMap(t *testing.T) {
+	parent := zinject.New()
+	parent.Register(&Greeter{"Parent"}, "greeter").Register(&Farewell{"Parent"}, "parent")
+
+	injector := parent.Child()
+	injector.Register(&Farewell{"Jeremy"}, "").
+		Register("not a stringer", "string").
+		Register(&Greeter{"Jeremy"}, "greeter")
+
+	s := CollectMapStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, len(s.Stringers), 3)
+	expect(t, s.Stringers[""].String(), "Goodbye, Jeremy")
+	expect(t, s.Stringers["greeter"].String(), "Hello, My name isJeremy")
+	expect(t, s.Stringers["parent"].String(), "Goodbye, Parent")
+}
+
+type CollectArrayStruct struct {
+	Stringers [2]fmt.Stringer `inject:"*"`
+}
+
+func Test_InjectorCollectArray(t *testing.T) {
+	injector := zinject.New()
+
+	// under-full
+	injector.Register(&Greeter{"Jeremy"}, "")
+	s := CollectArrayStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Stringers[0].String(), "Hello, My name isJeremy")
+	expect(t, s.Stringers[1], nil)
+
+	// exactly-full
+	injector.Register(&Farewell{"Jeremy"}, "")
+	s = CollectArrayStruct{}
+	err = injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Stringers[0].String(), "Hello, My name isJeremy")
+	expect(t, s.Stringers[1].String(), "Goodbye, Jeremy")
+
+	// over-full
+	injector.Register(&Greeter{"Jeremy"}, "other")
+	s = CollectArrayStruct{}
+	err = injector.Inject(&s)
+	expect(t, err.Error(), `inject: CollectArrayStruct.Stringers (key="*"): 3 values of type fmt.Stringer exceed the length of [2]fmt.Stringer`)
+	expect(t, s.Stringers[0], nil)
+}
+
+func Test_InjectorResolveAll(t *testing.T) {
+	parent := zinject.New()
+	parent.Register(&Greeter{"parent"}, "").Register(&Farewell{"parent"}, "bye")
+
+	injector := parent.Child()
+	injector.Register(&Greeter{"child"}, "")
+	injector.Register("not a stringer", "")
+	injector.Factory(func() (*Farewell, error) { return nil, errors.New("failed") }, "failing")
+	injector.Register(&Farewell{"child"}, "other")
+
+	stringer := zinject.InterfaceOf((*fmt.Stringer)(nil))
+	var names []string
+	for _, v := range injector.ResolveAll(stringer) {
+		names = append(names, v.Interface().(fmt.Stringer).String())
+	}
+	expect(t, strings.Join(names, "; "), "Hello, My name ischild; Goodbye, child; Goodbye, parent")
+
+	expect(t, len(zinject.New().ResolveAll(stringer)), 0)
+}
+
+type CyclicCollectStruct struct {
+	Greeters []*Greeter `inject:"*"`
+}
+
+func Test_InjectorCollectCycle(t *testing.T) {
+	injector := zinject.New()
+	injector.ProvideConstructor(func(g *Greeter) *Greeter { return g }, "")
+
+	expect(t, len(injector.ResolveAll(reflect.TypeOf(&Greeter{}))), 0)
+
+	s := CyclicCollectStruct{}
+	err := injector.Inject(&s)
+	var ce *zinject.CycleError
+	expect(t, errors.As(err, &ce), true)
+}
@@ -0,0 +1,18 @@
+package zinject
+
+import "context"
+
+// contextKey is the key of the Injector stored in a context.Context.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying inj, typically a request-scoped
+// child injector, so that it can be retrieved downstream with FromContext.
+func NewContext(ctx context.Context, inj Injector) context.Context {
+	return context.WithValue(ctx, contextKey{}, inj)
+}
+
+// FromContext returns the Injector carried by ctx, or nil if there is none.
+func FromContext(ctx context.Context) Injector {
+	inj, _ := ctx.Value(contextKey{}).(Injector)
+	return inj
+}
@@ -0,0 +1,31 @@
+package zinject_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_InjectorContext(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("app dep", "")
+
+	expect(t, zinject.FromContext(context.Background()), nil)
+
+	scope := injector.Child()
+	scope.Register("request dep", "")
+	ctx := zinject.NewContext(context.Background(), scope)
+	ctx = context.WithValue(ctx, ctxKey{}, "value")
+
+	handle := func(ctx context.Context) string {
+		return zinject.FromContext(ctx).Get(reflect.TypeOf("string"), "").String()
+	}
+	expect(t, handle(ctx), "request dep")
+	expect(t, zinject.FromContext(ctx), scope)
+
+	// a nested scope shadows the outer one
+	ctx = zinject.NewContext(ctx, zinject.New().Register("nested dep", ""))
+	expect(t, handle(ctx), "nested dep")
+}
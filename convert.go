@@ -0,0 +1,176 @@
+package zinject
+
+import (
+	"reflect"
+	"time"
+)
+
+// convert returns v as a Value of type t. Besides assignable values, it
+// accepts values of the same kind, such as named types and their underlying
+// type, and numeric values that fit in t. Integers are never converted to
+// strings and floating-point numbers are never truncated to integers.
+func convert(v reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	vt := v.Type()
+	if vt.AssignableTo(t) {
+		return v, true
+	}
+	if !vt.ConvertibleTo(t) {
+		return reflect.Value{}, false
+	}
+
+	vk, tk := kindClass(vt.Kind()), kindClass(t.Kind())
+	switch {
+	case vt.Kind() == t.Kind() && vk == otherKind:
+	case vk == intKind && (tk == intKind || tk == uintKind):
+		if overflowsInt(v.Int(), t) {
+			return reflect.Value{}, false
+		}
+	case vk == uintKind && (tk == intKind || tk == uintKind):
+		if overflowsUint(v.Uint(), t) {
+			return reflect.Value{}, false
+		}
+	case (vk == intKind || vk == uintKind || vk == floatKind) && tk == floatKind:
+		if vk == floatKind && reflect.Zero(t).OverflowFloat(v.Float()) {
+			return reflect.Value{}, false
+		}
+	default:
+		return reflect.Value{}, false
+	}
+	return v.Convert(t), true
+}
+
+const (
+	otherKind = iota
+	intKind
+	uintKind
+	floatKind
+)
+
+func kindClass(k reflect.Kind) int {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return intKind
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return uintKind
+	case reflect.Float32, reflect.Float64:
+		return floatKind
+	default:
+		return otherKind
+	}
+}
+
+func overflowsInt(n int64, t reflect.Type) bool {
+	if kindClass(t.Kind()) == uintKind {
+		return n < 0 || reflect.Zero(t).OverflowUint(uint64(n))
+	}
+	return reflect.Zero(t).OverflowInt(n)
+}
+
+func overflowsUint(n uint64, t reflect.Type) bool {
+	if kindClass(t.Kind()) == intKind {
+		return n > 1<<63-1 || reflect.Zero(t).OverflowInt(int64(n))
+	}
+	return reflect.Zero(t).OverflowUint(n)
+}
+
+func (inj *injector) SetNumericFallback(enabled bool) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.numericFallback = enabled
+}
+
+// get resolves the Value mapped to t and key like getValue, reporting the
+// lookup to the observer if there is one.
+func (inj *injector) get(t reflect.Type, key string, r *resolution) (reflect.Value, error) {
+	observer := inj.observer.Load()
+	if observer == nil {
+		return inj.getValue(t, key, r)
+	}
+
+	start := time.Now()
+	val, err := inj.getValue(t, key, r)
+	(*observer)(t, key, val.IsValid(), time.Since(start))
+	return val, err
+}
+
+// getValue resolves the Value mapped to t and key, falling back to a
+// convertible numeric value if t is numeric and the numeric fallback is
+// enabled. A nil r starts a new resolution.
+func (inj *injector) getValue(t reflect.Type, key string, r *resolution) (reflect.Value, error) {
+	val, err := inj.resolve(t, key, r)
+	if err != nil || val.IsValid() || kindClass(t.Kind()) == otherKind {
+		return val, err
+	}
+
+	inj.mu.RLock()
+	fallback := inj.numericFallback
+	inj.mu.RUnlock()
+	if !fallback {
+		return val, nil
+	}
+	return inj.resolveConvertible(t, key, r)
+}
+
+// overflows reports whether the numeric Value v can be converted to t, but
+// not without overflowing it.
+func overflows(v reflect.Value, t reflect.Type) bool {
+	vk, tk := kindClass(v.Kind()), kindClass(t.Kind())
+	switch {
+	case vk == intKind && (tk == intKind || tk == uintKind):
+		return overflowsInt(v.Int(), t)
+	case vk == uintKind && (tk == intKind || tk == uintKind):
+		return overflowsUint(v.Uint(), t)
+	case vk == floatKind && tk == floatKind:
+		return reflect.Zero(t).OverflowFloat(v.Float())
+	}
+	return false
+}
+
+// resolveConvertible looks for the first registration of the given key, in
+// the injector then in its parents, whose Value can be converted to t.
+// Providers are never called, only the values already created are
+// considered. Returns a *ConversionError if the only candidates overflow t.
+func (inj *injector) resolveConvertible(t reflect.Type, key string, r *resolution) (reflect.Value, error) {
+	if r == nil {
+		r = &resolution{}
+	}
+	key = inj.keyOf(key)
+	if err := r.enter(inj, t, key); err != nil {
+		return reflect.Value{}, err
+	}
+	defer r.leave()
+
+	var overflow error
+	for _, reg := range inj.Registrations() {
+		if reg.Key != key || reg.Type == t || !reg.Type.ConvertibleTo(t) {
+			continue
+		}
+		inj.mu.RLock()
+		_, created := inj.values[reg.Type][key]
+		inj.mu.RUnlock()
+		if !created {
+			continue
+		}
+		val, err := inj.lookup(reg.Type, key, r)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if !val.IsValid() {
+			continue
+		}
+		if cv, ok := convert(val, t); ok {
+			return cv, nil
+		}
+		if overflow == nil && overflows(val, t) {
+			overflow = &ConversionError{Type: t, Key: key, Value: val.Type()}
+		}
+	}
+	if parent, ok := inj.Parent().(*injector); ok {
+		val, err := parent.resolveConvertible(t, key, r)
+		if err != nil || val.IsValid() || overflow == nil {
+			return val, err
+		}
+	}
+	return reflect.Value{}, overflow
+}
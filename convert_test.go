@@ -0,0 +1,131 @@
+package zinject_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+type Port int
+
+type ConvertStruct struct {
+	Timeout int64   `inject:"timeout"`
+	Port    Port    `inject:"port"`
+	Ratio   float64 `inject:"ratio"`
+}
+
+type OverflowStruct struct {
+	Small int8 `inject:"small"`
+}
+
+type NoStringConversionStruct struct {
+	Name string `inject:"name"`
+}
+
+func Test_InjectorConvert(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(30, "timeout").Register(8080, "port").Register(float32(0.5), "ratio")
+
+	s := ConvertStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Timeout, int64(30))
+	expect(t, s.Port, Port(8080))
+	expect(t, s.Ratio, 0.5)
+}
+
+func Test_InjectorConvertExactFirst(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(30, "timeout").Register(int64(60), "timeout").
+		Register(Port(9090), "port").Register(8080, "port").
+		Register(0.25, "ratio")
+
+	s := ConvertStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Timeout, int64(60))
+	expect(t, s.Port, Port(9090))
+}
+
+func Test_InjectorConvertParent(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(30, "timeout").Register(8080, "port").Register(1, "ratio")
+
+	s := ConvertStruct{}
+	err := injector.Child().Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Timeout, int64(30))
+	expect(t, s.Ratio, 1.0)
+}
+
+func Test_InjectorConvertRefused(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(300, "small").Register(65, "name")
+
+	err := injector.Inject(&OverflowStruct{})
+	var ce *zinject.ConversionError
+	expect(t, errors.As(err, &ce), true)
+	expect(t, ce.Value, reflect.TypeOf(300))
+	expect(t, err.Error(), `inject: OverflowStruct.Small (key="small"): cannot convert value of type int with key "small" to type int8: value overflows`)
+
+	err = injector.Inject(&NoStringConversionStruct{})
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+}
+
+func Test_InjectorNumericFallback(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(8080, "port")
+	injector.Register(300, "small")
+	injector.Register("8080", "")
+
+	int64Type := reflect.TypeOf(int64(0))
+	expect(t, injector.Get(int64Type, "port").IsValid(), false)
+
+	injector.SetNumericFallback(true)
+	expect(t, injector.Get(int64Type, "port").Int(), int64(8080))
+	expect(t, injector.MustGet(reflect.TypeOf(uint16(0)), "port").Uint(), uint64(8080))
+	expect(t, injector.Get(reflect.TypeOf(0.0), "port").Float(), 8080.0)
+
+	// conversions overflowing the requested type are refused
+	_, err := injector.GetE(reflect.TypeOf(int8(0)), "small")
+	var ce *zinject.ConversionError
+	expect(t, errors.As(err, &ce), true)
+
+	// strings are never converted to numbers
+	expect(t, injector.Get(reflect.TypeOf(uint(0)), "").IsValid(), false)
+
+	child := injector.Child()
+	expect(t, child.Get(int64Type, "port").Int(), int64(8080))
+
+	injector.SetNumericFallback(false)
+	expect(t, injector.Get(int64Type, "port").IsValid(), false)
+}
+
+type MissingNameStruct struct {
+	Name string `inject:""`
+}
+
+func Test_InjectorConvertSkipsProviders(t *testing.T) {
+	injector := zinject.New()
+	called := 0
+	injector.Factory(func() (*Greeter, error) {
+		called++
+		return nil, errors.New("db down")
+	}, "")
+	injector.Factory(func() int64 {
+		called++
+		return 11
+	}, "port")
+
+	s := MissingNameStruct{}
+	err := injector.Inject(&s)
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+
+	c := ConvertStruct{}
+	refute(t, injector.Inject(&c), nil)
+	expect(t, called, 0)
+}
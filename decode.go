@@ -0,0 +1,101 @@
+package zinject
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Decoder parses a string into a Value of the type it is registered for,
+// see RegisterDecoder.
+type Decoder func(string) (reflect.Value, error)
+
+var stringType = reflect.TypeOf("")
+
+// builtinDecoders are the decoders used when none is registered for a type.
+var builtinDecoders = map[reflect.Type]Decoder{
+	reflect.TypeOf(time.Duration(0)): func(s string) (reflect.Value, error) {
+		d, err := time.ParseDuration(s)
+		return reflect.ValueOf(d), err
+	},
+	reflect.TypeOf(time.Time{}): func(s string) (reflect.Value, error) {
+		t, err := time.Parse(time.RFC3339, s)
+		return reflect.ValueOf(t), err
+	},
+}
+
+func (inj *injector) SetStringDecoding(enabled bool) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.stringDecoding = enabled
+}
+
+func (inj *injector) RegisterDecoder(typ reflect.Type, decoder Decoder) {
+	if typ == nil || decoder == nil {
+		panic("zinject: RegisterDecoder requires a type and a decoder")
+	}
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	if inj.decoders == nil {
+		inj.decoders = map[reflect.Type]Decoder{}
+	}
+	inj.decoders[typ] = decoder
+}
+
+// decoderOf returns the decoder for t if the string decoding is enabled,
+// nil otherwise.
+func (inj *injector) decoderOf(t reflect.Type) Decoder {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	if !inj.stringDecoding {
+		return nil
+	}
+	if decoder, found := inj.decoders[t]; found {
+		return decoder
+	}
+	return builtinDecoders[t]
+}
+
+// resolveDecoded resolves the string mapped to key and decodes it into a
+// Value of type t, if the string decoding is enabled and there is a decoder
+// for t. Dry resolutions return the zero Value of t without decoding.
+func (inj *injector) resolveDecoded(t reflect.Type, key string, r *resolution) (reflect.Value, error) {
+	decoder := inj.decoderOf(t)
+	if decoder == nil || t == stringType {
+		return reflect.Value{}, nil
+	}
+	s, err := inj.resolve(stringType, key, r)
+	if err != nil || !s.IsValid() {
+		return reflect.Value{}, err
+	}
+	if r != nil && r.dry {
+		return reflect.Zero(t), nil
+	}
+	v, err := decoder(s.String())
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("decoding %q as %v: %w", s.String(), t, err)
+	}
+	if !v.IsValid() {
+		return reflect.Value{}, fmt.Errorf("decoding %q as %v: decoder returned no value", s.String(), t)
+	}
+	if !v.Type().AssignableTo(t) {
+		return reflect.Value{}, fmt.Errorf("decoding %q as %v: decoder returned a %v", s.String(), t, v.Type())
+	}
+	return v, nil
+}
+
+// copyDecoders returns a copy of decoders, nil if it is empty.
+func copyDecoders(decoders map[reflect.Type]Decoder) map[reflect.Type]Decoder {
+	if len(decoders) == 0 {
+		return nil
+	}
+	c := make(map[reflect.Type]Decoder, len(decoders))
+	for t, decoder := range decoders {
+		c[t] = decoder
+	}
+	return c
+}
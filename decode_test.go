@@ -0,0 +1,70 @@
+package zinject_test
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zionkit/zinject"
+)
+
+type ConfigStruct struct {
+	Timeout time.Duration `inject:"timeout"`
+	Since   time.Time     `inject:"since"`
+	Addr    net.IP        `inject:"addr,optional"`
+}
+
+func Test_InjectorStringDecoding(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("1m30s", "timeout").Register("2024-01-02T15:04:05Z", "since")
+
+	s := ConfigStruct{}
+	refute(t, injector.Inject(&s), nil)
+
+	injector.SetStringDecoding(true)
+	expect(t, injector.Inject(&s), nil)
+	expect(t, s.Timeout, 90*time.Second)
+	expect(t, s.Since.Equal(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)), true)
+	expect(t, s.Addr == nil, true)
+
+	// a mapped value of the field type comes first
+	child := injector.Child()
+	child.Register(time.Second, "timeout").Register("127.0.0.1", "addr")
+	child.RegisterDecoder(reflect.TypeOf(net.IP{}), func(s string) (reflect.Value, error) {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return reflect.Value{}, errors.New("invalid IP address")
+		}
+		return reflect.ValueOf(ip), nil
+	})
+	expect(t, child.Inject(&s), nil)
+	expect(t, s.Timeout, time.Second)
+	expect(t, s.Addr.String(), "127.0.0.1")
+
+	// decoders are inherited but not shared with the parent
+	expect(t, child.Child().Inject(&s), nil)
+	s.Addr = nil
+	expect(t, injector.Inject(&s), nil)
+	expect(t, s.Addr == nil, true)
+}
+
+func Test_InjectorStringDecodingError(t *testing.T) {
+	injector := zinject.New()
+	injector.SetStringDecoding(true)
+	injector.Register("soon", "timeout").Register("2024-01-02T15:04:05Z", "since")
+
+	s := ConfigStruct{}
+	err := injector.Inject(&s)
+	refute(t, err, nil)
+	expect(t, strings.HasPrefix(err.Error(), `inject: ConfigStruct.Timeout (key="timeout"): decoding "soon" as time.Duration`), true)
+
+	injector.RegisterDecoder(reflect.TypeOf(time.Duration(0)), func(s string) (reflect.Value, error) {
+		return reflect.ValueOf(s), nil
+	})
+	err = injector.Inject(&s)
+	refute(t, err, nil)
+	expect(t, err.Error(), `inject: ConfigStruct.Timeout (key="timeout"): decoding "soon" as time.Duration: decoder returned a string`)
+}
@@ -0,0 +1,88 @@
+package zinject
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxSummary is the length after which value summaries are truncated.
+const maxSummary = 60
+
+// Returns a human readable description of the mappings of the injector
+// and of its parents, each parent being indented one level further.
+func (inj *injector) Dump() string {
+	var b strings.Builder
+	visited := map[*injector]bool{}
+	indent := ""
+	var cur Injector = inj
+	for cur != nil {
+		i, ok := cur.(*injector)
+		if !ok {
+			for _, line := range strings.SplitAfter(cur.Dump(), "\n") {
+				if line != "" {
+					b.WriteString(indent + line)
+				}
+			}
+			break
+		}
+		if visited[i] {
+			b.WriteString(indent + "(circular parent)\n")
+			break
+		}
+		visited[i] = true
+		i.dump(&b, indent)
+		cur = i.Parent()
+		if cur != nil {
+			b.WriteString(indent + "parent:\n")
+		}
+		indent += "  "
+	}
+	return b.String()
+}
+
+func (inj *injector) dump(b *strings.Builder, indent string) {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	if len(inj.order) == 0 {
+		b.WriteString(indent + "(empty)\n")
+		return
+	}
+
+	var types []reflect.Type
+	keys := map[reflect.Type][]string{}
+	for _, r := range inj.order {
+		if _, found := keys[r.Type]; !found {
+			types = append(types, r.Type)
+		}
+		keys[r.Type] = append(keys[r.Type], r.Key)
+	}
+
+	for _, t := range types {
+		fmt.Fprintf(b, "%s%v\n", indent, t)
+		for _, k := range keys[t] {
+			fmt.Fprintf(b, "%s  %q = %s\n", indent, k, inj.summary(t, k))
+		}
+	}
+}
+
+// summary returns a short description of the Value mapped to t and key.
+// The caller must hold inj.mu.
+func (inj *injector) summary(t reflect.Type, key string) string {
+	if p := inj.providers[t][key]; p != nil {
+		return fmt.Sprintf("<provider %v>", p.fn.Type())
+	}
+	v := inj.values[t][key]
+	if !v.IsValid() {
+		return "<invalid>"
+	}
+	s := fmt.Sprintf("%+v", v)
+	if v.CanInterface() {
+		s = fmt.Sprintf("%+v", v.Interface())
+	}
+	if len(s) > maxSummary {
+		s = s[:maxSummary] + "..."
+	}
+	return s
+}
@@ -0,0 +1,35 @@
+package zinject_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_InjectorDump(t *testing.T) {
+	parent := zinject.New()
+	parent.Register(11, "")
+
+	injector := parent.Child()
+	injector.Register("a dep", "").
+		Register(&Greeter{"Jeremy"}, "greeter").
+		Register("another dep", "other").
+		Register(strings.Repeat("a", 100), "long")
+	injector.Factory(func() *Database { return &Database{} }, "")
+
+	expect(t, injector.Dump(), `string
+  "" = a dep
+  "other" = another dep
+  "long" = `+strings.Repeat("a", 60)+`...
+*zinject_test.Greeter
+  "greeter" = Hello, My name isJeremy
+*zinject_test.Database
+  "" = <provider func() *zinject_test.Database>
+parent:
+  int
+    "" = 11
+`)
+
+	expect(t, zinject.New().Dump(), "(empty)\n")
+}
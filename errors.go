@@ -0,0 +1,111 @@
+package zinject
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrFrozen is raised by the calls changing the mappings or the parent of a
+// frozen injector.
+var ErrFrozen = errors.New("injector is frozen, its mappings cannot be changed")
+
+// NotFoundError is returned when no value is mapped to a type and key.
+// Struct and Field are set when the value was required by a struct field.
+type NotFoundError struct {
+	Type   reflect.Type
+	Key    string
+	Struct reflect.Type
+	Field  string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("inject: %s.%s (key=%q): no value for type %v", typeName(e.Struct), e.Field, e.Key, e.Type)
+	}
+	return fmt.Sprintf("Value not found for type %v", e.Type)
+}
+
+// typeName returns the name of t, or its description if it is unnamed.
+func typeName(t reflect.Type) string {
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return t.String()
+}
+
+// AmbiguousError is returned when an interface type is requested and several
+// mapped types implementing it are candidates for the same key. Registering
+// them under distinct keys, or mapping a value to the interface type itself,
+// resolves the ambiguity. Struct and Field are set when the value was
+// required by a struct field.
+type AmbiguousError struct {
+	Type       reflect.Type
+	Key        string
+	Candidates []reflect.Type
+	Struct     reflect.Type
+	Field      string
+}
+
+func (e *AmbiguousError) Error() string {
+	names := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		names[i] = c.String()
+	}
+	if e.Field != "" {
+		return fmt.Sprintf("inject: %s.%s (key=%q): ambiguous dependency for type %v: implemented by %s", typeName(e.Struct), e.Field, e.Key, e.Type, strings.Join(names, ", "))
+	}
+	return fmt.Sprintf("ambiguous dependency for type %v with key %q: implemented by %s", e.Type, e.Key, strings.Join(names, ", "))
+}
+
+// CycleError is returned when a dependency requires itself to be resolved.
+// Chain starts and ends with the type of that dependency.
+type CycleError struct {
+	Chain []reflect.Type
+}
+
+func (e *CycleError) Error() string {
+	names := make([]string, len(e.Chain))
+	for i, t := range e.Chain {
+		names[i] = t.String()
+	}
+	return fmt.Sprintf("circular dependency detected: %s", strings.Join(names, " -> "))
+}
+
+// DuplicateError is raised by registrations of an already mapped type and
+// key when the ErrorOnDuplicate override policy is set.
+type DuplicateError struct {
+	Type reflect.Type
+	Key  string
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("duplicate registration of type %v with key %q", e.Type, e.Key)
+}
+
+// PointerReceiverError is returned when an interface type is requested and
+// the only candidate is a value of type Value whose pointer type implements
+// the interface, but which cannot be addressed.
+type PointerReceiverError struct {
+	Type  reflect.Type
+	Key   string
+	Value reflect.Type
+}
+
+func (e *PointerReceiverError) Error() string {
+	return fmt.Sprintf("%v does not implement %v (methods have pointer receivers), register a %v instead", e.Value, e.Type, reflect.PointerTo(e.Value))
+}
+
+// ConversionError is returned when no value is mapped to a numeric type and
+// key, and the values of that key that could be converted to it overflow it.
+// Value is the type of the first of them.
+type ConversionError struct {
+	Type  reflect.Type
+	Key   string
+	Value reflect.Type
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("cannot convert value of type %v with key %q to type %v: value overflows", e.Value, e.Key, e.Type)
+}
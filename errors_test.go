@@ -0,0 +1,88 @@
+package zinject_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_NotFoundError(t *testing.T) {
+	injector := zinject.New()
+
+	err := injector.Inject(&NamedStruct{})
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+	expect(t, nf.Type, reflect.TypeOf("string"))
+	expect(t, nf.Key, "primary")
+	expect(t, nf.Struct, reflect.TypeOf(NamedStruct{}))
+	expect(t, nf.Field, "Primary")
+	expect(t, err.Error(), `inject: NamedStruct.Primary (key="primary"): no value for type string`)
+
+	_, err = injector.Invoke(func(int) {})
+	expect(t, errors.As(err, &nf), true)
+	expect(t, nf.Type, reflect.TypeOf(11))
+
+	_, err = injector.GetE(reflect.TypeOf(11), "key")
+	expect(t, errors.As(err, &nf), true)
+	expect(t, nf.Key, "key")
+}
+
+func Test_AmbiguousError(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(&Greeter{"Jeremy"}, "").Register(&Farewell{"Jeremy"}, "")
+
+	_, err := injector.GetE(zinject.InterfaceOf((*fmt.Stringer)(nil)), "")
+	var ae *zinject.AmbiguousError
+	expect(t, errors.As(err, &ae), true)
+	expect(t, len(ae.Candidates), 2)
+	expect(t, ae.Candidates[0], reflect.TypeOf(&Greeter{}))
+	expect(t, ae.Candidates[1], reflect.TypeOf(&Farewell{}))
+
+	err = injector.Inject(&StringerStruct{})
+	expect(t, errors.As(err, &ae), true)
+	expect(t, ae.Struct, reflect.TypeOf(StringerStruct{}))
+	expect(t, ae.Field, "Dep")
+}
+
+func Test_CycleError(t *testing.T) {
+	injector := zinject.New()
+	injector.ProvideConstructor(func(repo *Repository) *Database {
+		return &Database{}
+	}, "")
+	injector.ProvideConstructor(func(db *Database) *Repository {
+		return &Repository{DB: db}
+	}, "")
+
+	_, err := injector.GetE(reflect.TypeOf(&Database{}), "")
+	var ce *zinject.CycleError
+	expect(t, errors.As(err, &ce), true)
+	expect(t, len(ce.Chain), 3)
+	expect(t, ce.Chain[0], reflect.TypeOf(&Database{}))
+	expect(t, ce.Chain[1], reflect.TypeOf(&Repository{}))
+	expect(t, ce.Chain[2], reflect.TypeOf(&Database{}))
+}
+
+func Test_GetEErrors(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(&Greeter{"Jeremy"}, "").Register(&Farewell{"Jeremy"}, "").Register(300, "small")
+	injector.SetNumericFallback(true)
+
+	// Get only reports that the lookup failed, GetE tells why
+	stringer := zinject.InterfaceOf((*fmt.Stringer)(nil))
+	expect(t, injector.Get(stringer, "").IsValid(), false)
+	_, err := injector.GetE(stringer, "")
+	var ae *zinject.AmbiguousError
+	expect(t, errors.As(err, &ae), true)
+
+	expect(t, injector.Get(reflect.TypeOf(int8(0)), "small").IsValid(), false)
+	_, err = injector.GetE(reflect.TypeOf(int8(0)), "small")
+	var ce *zinject.ConversionError
+	expect(t, errors.As(err, &ce), true)
+
+	_, err = injector.GetE(reflect.TypeOf(int8(0)), "missing")
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+}
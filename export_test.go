@@ -0,0 +1,9 @@
+package zinject
+
+// Buckets returns the number of types inj holds values for.
+func Buckets(inj Injector) int {
+	i := inj.(*injector)
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.values)
+}
@@ -0,0 +1,16 @@
+package zinject
+
+func (inj *injector) Freeze() {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.frozen = true
+}
+
+// checkFrozen panics with ErrFrozen if the injector is frozen.
+// The caller must hold inj.mu.
+func (inj *injector) checkFrozen() {
+	if inj.frozen {
+		panic(ErrFrozen)
+	}
+}
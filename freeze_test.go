@@ -0,0 +1,46 @@
+package zinject_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func expectFrozen(t *testing.T, f func()) {
+	t.Helper()
+	defer func() {
+		expect(t, recover(), zinject.ErrFrozen)
+	}()
+	f()
+}
+
+func Test_InjectorFreeze(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+	injector.Factory(func() *Greeter { return &Greeter{"Jeremy"} }, "")
+	restore := injector.Snapshot()
+	injector.Freeze()
+
+	expectFrozen(t, func() { injector.Register("another dep", "") })
+	expectFrozen(t, func() { injector.Set(reflect.TypeOf(11), "", reflect.ValueOf(11)) })
+	expectFrozen(t, func() { injector.Factory(func() int { return 11 }, "") })
+	expectFrozen(t, func() { injector.Unregister(reflect.TypeOf("string"), "") })
+	expectFrozen(t, func() { injector.Clear() })
+	expectFrozen(t, func() { injector.SetParent(zinject.New()) })
+	expectFrozen(t, restore)
+
+	// lookups keep working, providers included
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "a dep")
+	expect(t, injector.Get(reflect.TypeOf(&Greeter{}), "").Interface().(*Greeter).Name, "Jeremy")
+	s := TestStruct{}
+	err := injector.Inject(&s)
+	refute(t, err, nil)
+	expect(t, s.Dep1, "a dep")
+	expect(t, injector.Unregister(reflect.TypeOf(11), ""), false)
+
+	// children start unfrozen
+	child := injector.Child()
+	child.Register("child dep", "")
+	expect(t, child.Get(reflect.TypeOf("string"), "").String(), "child dep")
+}
@@ -0,0 +1,53 @@
+package zinject
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// typeOf returns the static reflect.Type of T, interface types included.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Get returns the value of type T registered under key in inj. The second
+// return value reports whether a value was found, the zero value of T is
+// returned otherwise.
+func Get[T any](inj Injector, key string) (T, bool) {
+	var zero T
+	v := inj.Get(typeOf[T](), key)
+	if !v.IsValid() {
+		return zero, false
+	}
+	val, ok := v.Interface().(T)
+	if !ok {
+		return zero, false
+	}
+	return val, true
+}
+
+// Provide maps val under the static type T rather than its dynamic type,
+// which allows registering a concrete value directly as an interface, e.g.
+// Provide[io.Writer](inj, &bytes.Buffer{}, "").
+func Provide[T any](inj Injector, val T, key string) Injector {
+	return inj.Set(typeOf[T](), key, reflect.ValueOf(&val).Elem())
+}
+
+// RegisterAsType maps val under the interface type I, like RegisterAs
+// without the pointer to interface idiom: RegisterAsType[io.Writer](inj,
+// &bytes.Buffer{}, ""). Panics if I is not an interface type or if val is a
+// nil interface.
+func RegisterAsType[I any](inj Injector, val I, key string) Injector {
+	t := typeOf[I]()
+	if t.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("Called inject.RegisterAsType with type %v that is not an interface", t))
+	}
+	v := reflect.ValueOf(val)
+	if !v.IsValid() {
+		panic(fmt.Sprintf("Called inject.RegisterAsType with a nil value for interface %v", t))
+	}
+	if !v.Type().Implements(t) {
+		panic(fmt.Sprintf("Called inject.RegisterAsType with a value of type %v that does not implement %v", v.Type(), t))
+	}
+	return inj.Set(t, key, v)
+}
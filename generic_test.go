@@ -0,0 +1,137 @@
+package zinject_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_GenericGet(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("some dependency", "")
+	injector.Register(&Greeter{"Jeremy"}, "greeter")
+
+	s, ok := zinject.Get[string](injector, "")
+	expect(t, ok, true)
+	expect(t, s, "some dependency")
+
+	g, ok := zinject.Get[*Greeter](injector, "greeter")
+	expect(t, ok, true)
+	expect(t, g.Name, "Jeremy")
+
+	i, ok := zinject.Get[int](injector, "")
+	expect(t, ok, false)
+	expect(t, i, 0)
+}
+
+func Test_GenericGetInterface(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(&Greeter{"Jeremy"}, "")
+
+	s, ok := zinject.Get[fmt.Stringer](injector, "")
+	expect(t, ok, true)
+	expect(t, s.String(), "Hello, My name isJeremy")
+
+	var missing fmt.Stringer
+	missing, ok = zinject.Get[fmt.Stringer](injector, "missing")
+	expect(t, ok, false)
+	expect(t, missing, nil)
+}
+
+func Test_GenericProvide(t *testing.T) {
+	injector := zinject.New()
+	buf := &bytes.Buffer{}
+	zinject.Provide[io.Writer](injector, buf, "")
+
+	v := injector.Get(zinject.InterfaceOf((*io.Writer)(nil)), "")
+	expect(t, v.IsValid(), true)
+	expect(t, v.Type(), zinject.InterfaceOf((*io.Writer)(nil)))
+
+	w, ok := zinject.Get[io.Writer](injector, "")
+	expect(t, ok, true)
+	expect(t, w, io.Writer(buf))
+
+	// not registered under the concrete type
+	expect(t, injector.Get(reflect.TypeOf(buf), "").IsValid(), false)
+}
+
+func Test_GenericRegisterAsType(t *testing.T) {
+	injector := zinject.New()
+	buf := &bytes.Buffer{}
+	zinject.RegisterAsType[io.Writer](injector, buf, "")
+
+	w, ok := zinject.Get[io.Writer](injector, "")
+	expect(t, ok, true)
+	expect(t, w, io.Writer(buf))
+
+	func() {
+		defer func() {
+			expect(t, recover(), "Called inject.RegisterAsType with type *bytes.Buffer that is not an interface")
+		}()
+		zinject.RegisterAsType[*bytes.Buffer](injector, buf, "")
+	}()
+
+	func() {
+		defer func() {
+			expect(t, recover(), "Called inject.RegisterAsType with a nil value for interface io.Writer")
+		}()
+		zinject.RegisterAsType[io.Writer](injector, nil, "")
+	}()
+}
+
+type User struct{ Name string }
+
+type Order struct{ ID int }
+
+type GenericRepository[T any] struct {
+	Items []T
+}
+
+func (r *GenericRepository[T]) First() T {
+	return r.Items[0]
+}
+
+type GenericStore[T any] interface {
+	First() T
+}
+
+type GenericStruct struct {
+	Users  *GenericRepository[User] `inject:""`
+	Orders GenericStore[Order]      `inject:""`
+	All    []GenericStore[User]     `inject:"*"`
+}
+
+func Test_GenericInstantiations(t *testing.T) {
+	injector := zinject.New()
+	users := &GenericRepository[User]{Items: []User{{"Jeremy"}}}
+	orders := &GenericRepository[Order]{Items: []Order{{42}}}
+	injector.Register(users, "").Register(orders, "")
+
+	u, ok := zinject.Get[*GenericRepository[User]](injector, "")
+	expect(t, ok, true)
+	expect(t, u, users)
+	o, ok := zinject.Get[*GenericRepository[Order]](injector, "")
+	expect(t, ok, true)
+	expect(t, o, orders)
+	_, ok = zinject.Get[*GenericRepository[string]](injector, "")
+	expect(t, ok, false)
+
+	// each instantiation of the interface only matches its own
+	us, ok := zinject.Get[GenericStore[User]](injector, "")
+	expect(t, ok, true)
+	expect(t, us.First().Name, "Jeremy")
+	os, ok := zinject.Get[GenericStore[Order]](injector, "")
+	expect(t, ok, true)
+	expect(t, os.First().ID, 42)
+
+	s := GenericStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Users, users)
+	expect(t, s.Orders.First().ID, 42)
+	expect(t, len(s.All), 1)
+}
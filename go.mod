@@ -1,3 +1,3 @@
 module github.com/zionkit/zinject
 
-go 1.14
+go 1.20
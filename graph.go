@@ -0,0 +1,129 @@
+package zinject
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// graphNode is a mapping of an injector, with the argument types of its
+// constructor if it is mapped to a constructor provider not called yet.
+type graphNode struct {
+	reg  Registration
+	deps []reflect.Type
+}
+
+// graphLevel holds the mappings of one injector of the hierarchy.
+type graphLevel struct {
+	defaultKey string
+	nodes      []graphNode
+}
+
+// Returns the mappings of the injector and of its parents as a Graphviz DOT
+// digraph, each injector being drawn as a cluster. Constructor providers
+// not called yet have edges to the mappings their arguments resolve to,
+// arguments that cannot be resolved pointing to dashed red nodes.
+func (inj *injector) GraphDOT() string {
+	levels := inj.graphLevels()
+
+	var b strings.Builder
+	b.WriteString("digraph zinject {\n")
+	for i, l := range levels {
+		label := "injector"
+		if i > 0 {
+			label = fmt.Sprintf("parent %d", i)
+		}
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%q;\n", i, label)
+		for j, n := range l.nodes {
+			nodeLabel := n.reg.Type.String()
+			if n.reg.Key != "" {
+				nodeLabel += fmt.Sprintf("\n(%s)", n.reg.Key)
+			}
+			fmt.Fprintf(&b, "    n%d_%d [label=%q];\n", i, j, nodeLabel)
+		}
+		b.WriteString("  }\n")
+	}
+
+	missing := map[reflect.Type]int{}
+	for i, l := range levels {
+		for j, n := range l.nodes {
+			for _, dep := range n.deps {
+				if to, ok := graphTarget(levels[i:], dep); ok {
+					fmt.Fprintf(&b, "  n%d_%d -> %s;\n", i, j, to.id(i))
+					continue
+				}
+				m, found := missing[dep]
+				if !found {
+					m = len(missing)
+					missing[dep] = m
+					fmt.Fprintf(&b, "  missing_%d [label=%q, style=dashed, color=red];\n", m, dep.String())
+				}
+				fmt.Fprintf(&b, "  n%d_%d -> missing_%d;\n", i, j, m)
+			}
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// graphLevels snapshots the mappings of the injector and of its parents,
+// stopping at the first parent that is not an *injector.
+func (inj *injector) graphLevels() []graphLevel {
+	var levels []graphLevel
+	visited := map[*injector]bool{}
+	for cur := inj; cur != nil && !visited[cur]; {
+		visited[cur] = true
+		levels = append(levels, cur.graphLevel())
+		cur, _ = cur.Parent().(*injector)
+	}
+	return levels
+}
+
+func (inj *injector) graphLevel() graphLevel {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	l := graphLevel{defaultKey: inj.defaultKey}
+	for _, reg := range inj.order {
+		n := graphNode{reg: reg}
+		if p := inj.providers[reg.Type][reg.Key]; p != nil && p.constructor {
+			ft := p.fn.Type()
+			for i := 0; i < ft.NumIn(); i++ {
+				n.deps = append(n.deps, ft.In(i))
+			}
+		}
+		l.nodes = append(l.nodes, n)
+	}
+	return l
+}
+
+// graphRef identifies a node by its level and index.
+type graphRef struct {
+	level, index int
+}
+
+// id returns the DOT identifier of r, its level being relative to offset.
+func (r graphRef) id(offset int) string {
+	return fmt.Sprintf("n%d_%d", r.level+offset, r.index)
+}
+
+// graphTarget returns the node an argument of type t resolves to, looking
+// for t itself then for a type implementing it, level by level.
+func graphTarget(levels []graphLevel, t reflect.Type) (graphRef, bool) {
+	for i, l := range levels {
+		for j, n := range l.nodes {
+			if n.reg.Type == t && n.reg.Key == l.defaultKey {
+				return graphRef{i, j}, true
+			}
+		}
+		if t.Kind() != reflect.Interface {
+			continue
+		}
+		for j, n := range l.nodes {
+			if n.reg.Key == l.defaultKey && n.reg.Type.Implements(t) {
+				return graphRef{i, j}, true
+			}
+		}
+	}
+	return graphRef{}, false
+}
@@ -0,0 +1,41 @@
+package zinject_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_InjectorGraphDOT(t *testing.T) {
+	parent := zinject.New()
+	parent.Register(&Database{DSN: "dsn"}, "")
+
+	injector := parent.Child()
+	injector.Register("a dep", "name")
+	injector.ProvideConstructor(func(db *Database) *Repository { return &Repository{db} }, "")
+	injector.ProvideConstructor(func(repo *Repository, s fmt.Stringer, n int) *Service { return &Service{repo} }, "")
+	injector.Register(&Greeter{"Jeremy"}, "")
+
+	expect(t, injector.GraphDOT(), `digraph zinject {
+  subgraph cluster_0 {
+    label="injector";
+    n0_0 [label="string\n(name)"];
+    n0_1 [label="*zinject_test.Repository"];
+    n0_2 [label="*zinject_test.Service"];
+    n0_3 [label="*zinject_test.Greeter"];
+  }
+  subgraph cluster_1 {
+    label="parent 1";
+    n1_0 [label="*zinject_test.Database"];
+  }
+  n0_1 -> n1_0;
+  n0_2 -> n0_1;
+  n0_2 -> n0_3;
+  missing_0 [label="int", style=dashed, color=red];
+  n0_2 -> missing_0;
+}
+`)
+
+	expect(t, zinject.New().GraphDOT(), "digraph zinject {\n  subgraph cluster_0 {\n    label=\"injector\";\n  }\n}\n")
+}
@@ -0,0 +1,74 @@
+package zinject
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// methodPrefix is the prefix of the methods called by Inject.
+const methodPrefix = "Inject"
+
+// methodCache maps a reflect.Type to the indexes of its injection methods.
+var methodCache sync.Map
+
+// methodsOf returns the indexes of the injection methods of t: its exported
+// methods prefixed with "Inject", taking at least one argument and returning
+// nothing or an error. Methods of the Injector interface are left out, so
+// that a struct embedding an Injector does not have them called.
+func methodsOf(t reflect.Type) []int {
+	if methods, ok := methodCache.Load(t); ok {
+		return methods.([]int)
+	}
+
+	var methods []int
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if !strings.HasPrefix(m.Name, methodPrefix) {
+			continue
+		}
+		if _, ok := injectorType.MethodByName(m.Name); ok {
+			continue
+		}
+		mt := m.Type
+		if mt.NumIn() < 2 || mt.NumOut() > 1 || (mt.NumOut() == 1 && mt.Out(0) != errorType) {
+			continue
+		}
+		methods = append(methods, i)
+	}
+
+	actual, _ := methodCache.LoadOrStore(t, methods)
+	return actual.([]int)
+}
+
+// injectMethods calls the injection methods of the struct v, or of its
+// address if it is addressable, with their arguments resolved from the
+// injector.
+func (inj *injector) injectMethods(v reflect.Value, in *injection) error {
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+
+	for _, i := range methodsOf(v.Type()) {
+		m := v.Method(i)
+		args, err := inj.arguments(m.Type(), in.resolution(), nil)
+		if err != nil {
+			err = fmt.Errorf("inject: %s.%s: %w", typeName(reflect.Indirect(v).Type()), v.Type().Method(i).Name, err)
+			if err = in.fail(err); err != nil {
+				return err
+			}
+			continue
+		}
+		if in.dry {
+			continue
+		}
+		out := call(m, args)
+		if len(out) == 1 && !out[0].IsNil() {
+			if err := in.fail(out[0].Interface().(error)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
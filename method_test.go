@@ -0,0 +1,79 @@
+package zinject_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+type MethodStruct struct {
+	Dep      string `inject:""`
+	name     string
+	port     int
+	injected int
+}
+
+func (s *MethodStruct) InjectName(name string, port int) {
+	s.name, s.port = name, port
+	s.injected++
+}
+
+func (s *MethodStruct) InjectCheck(name string) error {
+	if name == "" {
+		return errors.New("empty name")
+	}
+	return nil
+}
+
+// Injected takes no argument and is not called.
+func (s *MethodStruct) Injected() bool {
+	return s.injected > 0
+}
+
+func (s *MethodStruct) Init() error {
+	if s.name != s.Dep {
+		return errors.New("methods not called before Init")
+	}
+	return nil
+}
+
+type MethodInjectorStruct struct {
+	zinject.Injector
+}
+
+func Test_InjectorInjectMethods(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("Jeremy", "")
+	injector.Register(8080, "")
+
+	s := MethodStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.name, "Jeremy")
+	expect(t, s.port, 8080)
+	expect(t, s.injected, 1)
+	expect(t, s.Injected(), true)
+
+	// Injector methods promoted from an embedded Injector are not called
+	e := MethodInjectorStruct{Injector: zinject.New()}
+	err = injector.Inject(&e)
+	expect(t, err, nil)
+}
+
+func Test_InjectorInjectMethodsError(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("Jeremy", "")
+
+	s := MethodStruct{}
+	err := injector.Inject(&s)
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+	expect(t, err.Error(), "inject: MethodStruct.InjectName: Value not found for type int (argument 1)")
+
+	injector = zinject.New()
+	injector.Register("", "")
+	injector.Register(8080, "")
+	err = injector.Inject(&s)
+	expect(t, err.Error(), "empty name")
+}
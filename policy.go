@@ -0,0 +1,82 @@
+package zinject
+
+import (
+	"log"
+	"reflect"
+)
+
+// OverridePolicy defines how an injector reacts when a type and key that
+// is already mapped, in the injector itself or in one of its parents, is
+// registered again.
+type OverridePolicy int
+
+const (
+	// AllowSilent replaces or shadows the existing mapping, the default.
+	AllowSilent OverridePolicy = iota
+	// WarnOnShadow replaces or shadows the existing mapping and logs a
+	// warning.
+	WarnOnShadow
+	// ErrorOnDuplicate panics with a *DuplicateError.
+	ErrorOnDuplicate
+)
+
+func (inj *injector) SetOverridePolicy(policy OverridePolicy) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.policy = policy
+}
+
+// checkOverride applies the override policy to a new registration of the
+// given type and key.
+func (inj *injector) checkOverride(typ reflect.Type, key string) {
+	inj.mu.RLock()
+	policy := inj.policy
+	inj.mu.RUnlock()
+
+	if policy == AllowSilent || !inj.Has(typ, key) {
+		return
+	}
+	err := &DuplicateError{Type: typ, Key: key}
+	if policy == ErrorOnDuplicate {
+		panic(err)
+	}
+	log.Printf("zinject: %v", err)
+}
+
+// ImplementorPolicy defines how an injector picks among several mapped types
+// implementing a requested interface under the same key.
+type ImplementorPolicy int
+
+const (
+	// ErrorOnAmbiguous refuses to pick one, the lookup failing with an
+	// *AmbiguousError, the default.
+	ErrorOnAmbiguous ImplementorPolicy = iota
+	// FirstRegistered picks the implementor mapped first.
+	FirstRegistered
+	// LastRegistered picks the implementor mapped last.
+	LastRegistered
+)
+
+func (inj *injector) SetImplementorPolicy(policy ImplementorPolicy) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.implementorPolicy = policy
+}
+
+// pickImplementor returns the candidate picked by the implementor policy
+// among candidates, in registration order, or false if it picks none.
+func (inj *injector) pickImplementor(candidates []reflect.Type) (reflect.Type, bool) {
+	inj.mu.RLock()
+	policy := inj.implementorPolicy
+	inj.mu.RUnlock()
+
+	switch policy {
+	case FirstRegistered:
+		return candidates[0], true
+	case LastRegistered:
+		return candidates[len(candidates)-1], true
+	}
+	return nil, false
+}
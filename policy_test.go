@@ -0,0 +1,83 @@
+package zinject_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_InjectorOverridePolicyAllowSilent(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "").Register("another dep", "")
+
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "another dep")
+}
+
+func Test_InjectorOverridePolicyWarnOnShadow(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	injector := zinject.New()
+	injector.SetOverridePolicy(zinject.WarnOnShadow)
+	injector.Register("a dep", "")
+	expect(t, buf.Len(), 0)
+
+	injector.Child().Register("another dep", "")
+	expect(t, bytes.Contains(buf.Bytes(), []byte(`duplicate registration of type string with key ""`)), true)
+}
+
+func Test_InjectorOverridePolicyErrorOnDuplicate(t *testing.T) {
+	injector := zinject.New()
+	injector.SetOverridePolicy(zinject.ErrorOnDuplicate)
+	injector.Register("a dep", "").Register("another dep", "other")
+
+	for _, inj := range []zinject.Injector{injector, injector.Child()} {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				var de *zinject.DuplicateError
+				expect(t, errors.As(err, &de), true)
+				expect(t, de.Key, "")
+			}()
+			inj.Register("again", "")
+		}()
+	}
+
+	injector.Child().Register("a dep", "child")
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "a dep")
+}
+
+func Test_InjectorImplementorPolicy(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(&Greeter{"Jeremy"}, "").Register(&Farewell{"Jeremy"}, "")
+	stringerType := zinject.InterfaceOf((*fmt.Stringer)(nil))
+
+	_, err := injector.GetE(stringerType, "")
+	var ae *zinject.AmbiguousError
+	expect(t, errors.As(err, &ae), true)
+
+	injector.SetImplementorPolicy(zinject.FirstRegistered)
+	expect(t, injector.Get(stringerType, "").Interface().(fmt.Stringer).String(), "Hello, My name isJeremy")
+
+	child := injector.Child()
+	child.Register(&Greeter{"Child"}, "").Register(&Farewell{"Child"}, "")
+	s := StringerStruct{}
+	expect(t, child.Inject(&s), nil)
+	expect(t, s.Dep.String(), "Hello, My name isChild")
+
+	// mapping a type again keeps its position
+	injector.SetImplementorPolicy(zinject.LastRegistered)
+	injector.Register(&Greeter{"Again"}, "")
+	expect(t, injector.Get(stringerType, "").Interface().(fmt.Stringer).String(), "Goodbye, Jeremy")
+
+	injector.SetImplementorPolicy(zinject.ErrorOnAmbiguous)
+	_, err = injector.GetE(stringerType, "")
+	expect(t, errors.As(err, &ae), true)
+}
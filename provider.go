@@ -0,0 +1,241 @@
+package zinject
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	injectorType = reflect.TypeOf((*Injector)(nil)).Elem()
+)
+
+// provider creates the Value of a dependency on demand.
+type provider struct {
+	fn reflect.Value
+	// constructor providers have their arguments resolved from the injector,
+	// factories are only given the injector itself.
+	constructor bool
+	// transient providers are called each time they are requested, with the
+	// injector the request was made to.
+	transient bool
+
+	// mu serializes calls so that the function succeeds at most once, val
+	// holding its result once done.
+	mu   sync.Mutex
+	done bool
+	val  reflect.Value
+}
+
+// Maps the first return type of fn to a provider calling fn lazily.
+func (inj *injector) Factory(fn interface{}, key string) Injector {
+	fv := reflect.ValueOf(fn)
+	if !isFactory(fv) {
+		panic(fmt.Sprintf("Called inject.Factory with a value that is not a factory function: %v", reflect.TypeOf(fn)))
+	}
+
+	inj.setProvider(fv.Type().Out(0), key, &provider{fn: fv})
+	return inj
+}
+
+// Maps the first return type of fn, a func(Injector) T optionally returning
+// an error, to a provider called with the requesting injector each time the
+// dependency is requested.
+func (inj *injector) Provider(fn interface{}, key string) Injector {
+	fv := reflect.ValueOf(fn)
+	if !isFactory(fv) || fv.Type().NumIn() != 1 {
+		panic(fmt.Sprintf("Called inject.Provider with a value that is not a provider function: %v", reflect.TypeOf(fn)))
+	}
+
+	inj.setProvider(fv.Type().Out(0), key, &provider{fn: fv, transient: true})
+	return inj
+}
+
+// Maps the first return type of fn to a provider calling fn lazily with
+// its arguments resolved from the injector.
+func (inj *injector) ProvideConstructor(fn interface{}, key string) Injector {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func || !hasProviderResults(fv.Type()) {
+		panic(fmt.Sprintf("Called inject.ProvideConstructor with a value that is not a constructor function: %v", reflect.TypeOf(fn)))
+	}
+
+	inj.setProvider(fv.Type().Out(0), key, &provider{fn: fv, constructor: true})
+	return inj
+}
+
+// Maps the first return type of fn to a provider calling fn with its
+// arguments resolved from the requesting injector each time the dependency
+// is requested.
+func (inj *injector) ProvideTransient(fn interface{}, key string) Injector {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func || !hasProviderResults(fv.Type()) {
+		panic(fmt.Sprintf("Called inject.ProvideTransient with a value that is not a constructor function: %v", reflect.TypeOf(fn)))
+	}
+
+	inj.setProvider(fv.Type().Out(0), key, &provider{fn: fv, constructor: true, transient: true})
+	return inj
+}
+
+func (inj *injector) setProvider(typ reflect.Type, key string, p *provider) {
+	key = inj.keyOf(key)
+	inj.checkOverride(typ, key)
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.checkFrozen()
+	inj.track(typ, key)
+	inj.removeValue(typ, key)
+	m := inj.providers[typ]
+	if m == nil {
+		m = map[string]*provider{}
+		inj.providers[typ] = m
+	}
+	m[key] = p
+}
+
+// isFactory reports whether fv is a func() T, func(Injector) T, or one of
+// these returning an additional error.
+func isFactory(fv reflect.Value) bool {
+	if fv.Kind() != reflect.Func {
+		return false
+	}
+	t := fv.Type()
+	switch t.NumIn() {
+	case 0:
+	case 1:
+		if t.In(0) != injectorType {
+			return false
+		}
+	default:
+		return false
+	}
+	return hasProviderResults(t)
+}
+
+// hasProviderResults reports whether the function type t returns a single
+// value, optionally followed by an error.
+func hasProviderResults(t reflect.Type) bool {
+	switch t.NumOut() {
+	case 1:
+		return true
+	case 2:
+		return t.Out(1) == errorType
+	default:
+		return false
+	}
+}
+
+// provide calls the provider p, and on success replaces it with the Value
+// it returned unless p is transient.
+func (inj *injector) provide(t reflect.Type, key string, p *provider, r *resolution) (reflect.Value, error) {
+	if p.transient {
+		return inj.callProvider(t, p, r)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return p.val, nil
+	}
+
+	val, err := inj.callProvider(t, p, r)
+	if err != nil || (r != nil && r.dry) {
+		return val, err
+	}
+
+	p.done, p.val = true, val
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	// the provider may have been replaced while it was called
+	if inj.providers[t][key] == p {
+		inj.removeProvider(t, key)
+		inj.mapOf(t)[key] = p.val
+	}
+	return p.val, nil
+}
+
+// Checks every provider not called yet with a dry resolution.
+func (inj *injector) Validate() error {
+	inj.mu.RLock()
+	var regs []Registration
+	for _, reg := range inj.order {
+		if inj.providers[reg.Type][reg.Key] != nil {
+			regs = append(regs, reg)
+		}
+	}
+	inj.mu.RUnlock()
+
+	var errs []error
+	for _, reg := range regs {
+		if _, err := inj.resolve(reg.Type, reg.Key, &resolution{dry: true}); err != nil {
+			errs = append(errs, fmt.Errorf("provider of type %v with key %q: %w", reg.Type, reg.Key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// callProvider calls the function of p. Transient providers are given the
+// injector the resolution started from, so that they see the mappings of
+// the child scope they are requested from. The other providers are given
+// the injector owning them, their result being shared by every scope.
+func (inj *injector) callProvider(t reflect.Type, p *provider, r *resolution) (reflect.Value, error) {
+	scope := inj
+	if p.transient && r != nil && r.origin != nil {
+		scope = r.origin
+	}
+	if r != nil && r.origin != scope {
+		origin := r.origin
+		r.origin = scope
+		defer func() { r.origin = origin }()
+	}
+
+	var in []reflect.Value
+	if p.constructor {
+		var err error
+		if in, err = scope.arguments(p.fn.Type(), r, nil); err != nil {
+			return reflect.Value{}, err
+		}
+	} else if p.fn.Type().NumIn() == 1 {
+		var self Injector = scope
+		if r != nil {
+			self = scope.bind(r.stack, r.dry)
+		}
+		in = []reflect.Value{reflect.ValueOf(&self).Elem()}
+	}
+	if r != nil && r.dry {
+		return reflect.Zero(t), nil
+	}
+
+	out := call(p.fn, in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, out[1].Interface().(error)
+	}
+	return out[0], nil
+}
+
+// removeValue removes the Value mapped to t and key.
+// The caller must hold inj.mu.
+func (inj *injector) removeValue(t reflect.Type, key string) {
+	delete(inj.expiries, Registration{Type: t, Key: key})
+	delete(inj.qualifiers, Registration{Type: t, Key: key})
+	if m := inj.values[t]; m != nil {
+		delete(m, key)
+		if len(m) == 0 {
+			delete(inj.values, t)
+		}
+	}
+}
+
+// removeProvider removes the provider mapped to t and key.
+// The caller must hold inj.mu.
+func (inj *injector) removeProvider(t reflect.Type, key string) {
+	if m := inj.providers[t]; m != nil {
+		delete(m, key)
+		if len(m) == 0 {
+			delete(inj.providers, t)
+		}
+	}
+}
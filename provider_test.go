@@ -0,0 +1,435 @@
+package zinject_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_InjectorFactory(t *testing.T) {
+	injector := zinject.New()
+	calls := 0
+	injector.Factory(func() *Greeter {
+		calls++
+		return &Greeter{"Jeremy"}
+	}, "")
+
+	expect(t, calls, 0)
+	expect(t, injector.Has(reflect.TypeOf(&Greeter{}), ""), true)
+
+	g1 := injector.Get(reflect.TypeOf(&Greeter{}), "")
+	expect(t, g1.IsValid(), true)
+	g2 := injector.Get(reflect.TypeOf(&Greeter{}), "")
+	expect(t, g1.Interface(), g2.Interface())
+	expect(t, calls, 1)
+
+	s := StringerStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Dep, g1.Interface())
+}
+
+func Test_InjectorFactoryInjector(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("Jeremy", "")
+	injector.Factory(func(inj zinject.Injector) *Greeter {
+		return &Greeter{inj.Get(reflect.TypeOf("string"), "").String()}
+	}, "")
+
+	g, ok := zinject.Get[*Greeter](injector, "")
+	expect(t, ok, true)
+	expect(t, g.Name, "Jeremy")
+}
+
+func Test_InjectorFactorySelf(t *testing.T) {
+	injector := zinject.New()
+	injector.Factory(func(inj zinject.Injector) (*Greeter, error) {
+		_, err := inj.GetE(reflect.TypeOf(&Greeter{}), "")
+		return &Greeter{"Jeremy"}, err
+	}, "")
+
+	_, err := injector.GetE(reflect.TypeOf(&Greeter{}), "")
+	var ce *zinject.CycleError
+	expect(t, errors.As(err, &ce), true)
+	expect(t, fmt.Sprint(err), "circular dependency detected: *zinject_test.Greeter -> *zinject_test.Greeter")
+
+	// Get swallows the error, the factory completes
+	injector.Factory(func(inj zinject.Injector) *Greeter {
+		expect(t, inj.Get(reflect.TypeOf(&Greeter{}), "").IsValid(), false)
+		return &Greeter{"Jeremy"}
+	}, "")
+	g, ok := zinject.Get[*Greeter](injector, "")
+	expect(t, ok, true)
+	expect(t, g.Name, "Jeremy")
+}
+
+type GreeterHolder struct {
+	Greeter *Greeter `inject:""`
+}
+
+func Test_InjectorFactorySelfInject(t *testing.T) {
+	injector := zinject.New()
+	injector.Factory(func(inj zinject.Injector) (*Greeter, error) {
+		var ce *zinject.CycleError
+		err := inj.Inject(&GreeterHolder{})
+		expect(t, errors.As(err, &ce), true)
+		_, err = inj.Invoke(func(*Greeter) {})
+		expect(t, errors.As(err, &ce), true)
+		_, err = inj.InvokeWith(func(*Greeter, string) {}, "")
+		expect(t, errors.As(err, &ce), true)
+		_, err = inj.GetAndInject(reflect.TypeOf(&Greeter{}), "")
+		expect(t, errors.As(err, &ce), true)
+		expect(t, len(inj.ResolveAll(reflect.TypeOf(&Greeter{}))), 0)
+		err = inj.InjectAll(&GreeterHolder{}, &GreeterHolder{})
+		expect(t, errors.As(err, &ce), true)
+		return &Greeter{"Jeremy"}, nil
+	}, "")
+
+	g, ok := zinject.Get[*Greeter](injector, "")
+	expect(t, ok, true)
+	expect(t, g.Name, "Jeremy")
+}
+
+func Test_InjectorProvideConstructorInjectorFromChild(t *testing.T) {
+	parent := zinject.New()
+	parent.Register("parent", "")
+	parent.ProvideConstructor(func(inj zinject.Injector) *Greeter {
+		return &Greeter{inj.Get(reflect.TypeOf("string"), "").String()}
+	}, "")
+
+	child := parent.Child()
+	child.Register("child", "")
+	v, err := child.GetE(reflect.TypeOf(&Greeter{}), "")
+	expect(t, err, nil)
+	expect(t, v.Interface().(*Greeter).Name, "parent")
+	expect(t, parent.Get(reflect.TypeOf(&Greeter{}), "").Interface(), v.Interface())
+}
+
+func Test_InjectorFactoryError(t *testing.T) {
+	injector := zinject.New()
+	fail := true
+	injector.Factory(func() (*Greeter, error) {
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		return &Greeter{"Jeremy"}, nil
+	}, "")
+
+	typ := reflect.TypeOf(&Greeter{})
+	expect(t, injector.Get(typ, "").IsValid(), false)
+
+	_, err := injector.GetE(typ, "")
+	refute(t, err, nil)
+	expect(t, err.Error(), "connection refused")
+
+	s := StringerStruct{}
+	err = injector.Inject(&s)
+	refute(t, err, nil)
+
+	fail = false
+	v, err := injector.GetE(typ, "")
+	expect(t, err, nil)
+	expect(t, v.Interface().(*Greeter).Name, "Jeremy")
+}
+
+func Test_InjectorFactoryInvalid(t *testing.T) {
+	injector := zinject.New()
+
+	for _, fn := range []interface{}{
+		"not a function",
+		func() {},
+		func(s string) string { return s },
+		func() (string, string) { return "", "" },
+	} {
+		func() {
+			defer func() {
+				refute(t, recover(), nil)
+			}()
+			injector.Factory(fn, "")
+			t.Errorf("Expected a panic for %v", reflect.TypeOf(fn))
+		}()
+	}
+}
+
+func Test_InjectorGetE(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("some dependency", "")
+
+	v, err := injector.GetE(reflect.TypeOf("string"), "")
+	expect(t, err, nil)
+	expect(t, v.String(), "some dependency")
+
+	_, err = injector.GetE(reflect.TypeOf(11), "")
+	expect(t, fmt.Sprint(err), "Value not found for type int")
+}
+
+type Database struct {
+	DSN string
+}
+
+type Repository struct {
+	DB *Database
+}
+
+type Service struct {
+	Repo *Repository
+}
+
+func Test_InjectorProvideConstructor(t *testing.T) {
+	injector := zinject.New()
+	calls := 0
+	injector.ProvideConstructor(func(repo *Repository) *Service {
+		return &Service{Repo: repo}
+	}, "")
+	injector.ProvideConstructor(func(db *Database) (*Repository, error) {
+		calls++
+		return &Repository{DB: db}, nil
+	}, "")
+	injector.Register(&Database{DSN: "mysql://"}, "")
+
+	var svc *Service
+	_, err := injector.Invoke(func(s *Service) {
+		svc = s
+	})
+	expect(t, err, nil)
+	expect(t, svc.Repo.DB.DSN, "mysql://")
+
+	repo, _ := zinject.Get[*Repository](injector, "")
+	expect(t, repo, svc.Repo)
+	expect(t, calls, 1)
+}
+
+func Test_InjectorProvideConstructorMissing(t *testing.T) {
+	injector := zinject.New()
+	injector.ProvideConstructor(func(db *Database) *Repository {
+		return &Repository{DB: db}
+	}, "")
+
+	_, err := injector.GetE(reflect.TypeOf(&Repository{}), "")
+	expect(t, fmt.Sprint(err), "Value not found for type *zinject_test.Database (argument 0)")
+}
+
+func Test_InjectorProvideConstructorCircular(t *testing.T) {
+	injector := zinject.New()
+	injector.ProvideConstructor(func(repo *Repository) *Database {
+		return &Database{}
+	}, "")
+	injector.ProvideConstructor(func(db *Database) *Repository {
+		return &Repository{DB: db}
+	}, "")
+
+	_, err := injector.GetE(reflect.TypeOf(&Repository{}), "")
+	expect(t, fmt.Sprint(err), "circular dependency detected: *zinject_test.Repository -> *zinject_test.Database -> *zinject_test.Repository")
+}
+
+func Test_InjectorFactoryConcurrent(t *testing.T) {
+	injector := zinject.New()
+	var calls int32
+	injector.Factory(func() *Database {
+		atomic.AddInt32(&calls, 1)
+		return &Database{}
+	}, "")
+
+	var wg sync.WaitGroup
+	results := make([]*Database, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = zinject.Get[*Database](injector, "")
+		}(i)
+	}
+	wg.Wait()
+
+	expect(t, calls, int32(1))
+	for _, r := range results {
+		expect(t, r, results[0])
+	}
+}
+
+func Test_InjectorValidate(t *testing.T) {
+	injector := zinject.New()
+	calls := 0
+	injector.ProvideConstructor(func(repo *Repository) *Service {
+		calls++
+		return &Service{Repo: repo}
+	}, "")
+	injector.ProvideConstructor(func(db *Database) *Repository {
+		calls++
+		return &Repository{DB: db}
+	}, "")
+	injector.Factory(func() (*Database, error) {
+		calls++
+		return &Database{}, nil
+	}, "")
+
+	expect(t, injector.Validate(), nil)
+	expect(t, calls, 0)
+	expect(t, zinject.New().Validate(), nil)
+}
+
+func Test_InjectorValidateErrors(t *testing.T) {
+	injector := zinject.New()
+	injector.ProvideConstructor(func(repo *Repository) *Service {
+		return &Service{Repo: repo}
+	}, "")
+	injector.ProvideConstructor(func(db *Database) *Repository {
+		return &Repository{DB: db}
+	}, "")
+	injector.ProvideConstructor(func(name string, s *Service) *Greeter {
+		return &Greeter{}
+	}, "")
+	injector.ProvideConstructor(func(g *Farewell) *Farewell {
+		return g
+	}, "")
+
+	err := injector.Validate()
+	expect(t, err.Error(), `provider of type *zinject_test.Service with key "": Value not found for type *zinject_test.Database (argument 0)
+provider of type *zinject_test.Repository with key "": Value not found for type *zinject_test.Database (argument 0)
+provider of type *zinject_test.Greeter with key "": Value not found for type string (argument 0)
+provider of type *zinject_test.Farewell with key "": circular dependency detected: *zinject_test.Farewell -> *zinject_test.Farewell`)
+
+	var ce *zinject.CycleError
+	expect(t, errors.As(err, &ce), true)
+}
+
+func Test_InjectorProvider(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("root", "")
+	injector.Provider(func(inj zinject.Injector) (*Greeter, error) {
+		name, ok := zinject.Get[string](inj, "")
+		if !ok {
+			return nil, errors.New("no name")
+		}
+		return &Greeter{name}, nil
+	}, "")
+
+	g, ok := zinject.Get[*Greeter](injector, "")
+	expect(t, ok, true)
+	expect(t, g.Name, "root")
+
+	child := injector.Child()
+	child.Register("request", "")
+	g, ok = zinject.Get[*Greeter](child, "")
+	expect(t, ok, true)
+	expect(t, g.Name, "request")
+
+	s := StringerStruct{}
+	err := child.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Dep.String(), "Hello, My name isrequest")
+
+	// not cached in the injector it was mapped in
+	g, _ = zinject.Get[*Greeter](injector, "")
+	expect(t, g.Name, "root")
+
+	injector.Unregister(reflect.TypeOf("string"), "")
+	_, err = injector.GetE(reflect.TypeOf(&Greeter{}), "")
+	expect(t, fmt.Sprint(err), "no name")
+}
+
+func Test_InjectorProviderSelf(t *testing.T) {
+	injector := zinject.New()
+	injector.Provider(func(inj zinject.Injector) (*Greeter, error) {
+		_, err := inj.GetE(reflect.TypeOf(&Greeter{}), "")
+		return &Greeter{"Jeremy"}, err
+	}, "")
+
+	_, err := injector.Child().GetE(reflect.TypeOf(&Greeter{}), "")
+	var ce *zinject.CycleError
+	expect(t, errors.As(err, &ce), true)
+
+	s := StringerStruct{}
+	err = injector.Inject(&s)
+	expect(t, errors.As(err, &ce), true)
+}
+
+func Test_InjectorProviderSelfInject(t *testing.T) {
+	injector := zinject.New()
+	injector.Provider(func(inj zinject.Injector) (*Greeter, error) {
+		s := GreeterHolder{}
+		if err := inj.Inject(&s); err != nil {
+			return nil, err
+		}
+		return s.Greeter, nil
+	}, "")
+
+	_, err := injector.GetE(reflect.TypeOf(&Greeter{}), "")
+	var ce *zinject.CycleError
+	expect(t, errors.As(err, &ce), true)
+
+	err = injector.Child().Inject(&GreeterHolder{})
+	expect(t, errors.As(err, &ce), true)
+}
+
+func Test_InjectorProvideTransient(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("root", "")
+	injector.ProvideTransient(func(name string) *Greeter { return &Greeter{name} }, "")
+
+	g1, ok := zinject.Get[*Greeter](injector, "")
+	expect(t, ok, true)
+	g2, _ := zinject.Get[*Greeter](injector, "")
+	expect(t, g1.Name, "root")
+	expect(t, g1 != g2, true)
+
+	child := injector.Child()
+	child.Register("request", "")
+	g, _ := zinject.Get[*Greeter](child, "")
+	expect(t, g.Name, "request")
+	g, _ = zinject.Get[*Greeter](injector, "")
+	expect(t, g.Name, "root")
+
+	// the copy stays transient
+	copied := zinject.New()
+	copied.Register("copy", "")
+	injector.CopyTo(copied)
+	g1, _ = zinject.Get[*Greeter](copied, "")
+	g2, _ = zinject.Get[*Greeter](copied, "")
+	expect(t, g1.Name, "copy")
+	expect(t, g1 != g2, true)
+
+	injector.Unregister(reflect.TypeOf("string"), "")
+	_, err := injector.GetE(reflect.TypeOf(&Greeter{}), "")
+	refute(t, err, nil)
+	refute(t, injector.Validate(), nil)
+
+	defer func() {
+		refute(t, recover(), nil)
+	}()
+	injector.ProvideTransient("not a function", "")
+}
+
+func Test_InjectorRegisterFunc(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("dsn", "")
+
+	err := injector.RegisterFunc(func(dsn string) (*Database, fmt.Stringer, error) {
+		return &Database{DSN: dsn}, &Greeter{"Jeremy"}, nil
+	}, "")
+	expect(t, err, nil)
+	expect(t, injector.Get(reflect.TypeOf(&Database{}), "").Interface().(*Database).DSN, "dsn")
+	// registered by its dynamic type
+	expect(t, injector.Has(reflect.TypeOf(&Greeter{}), ""), true)
+
+	err = injector.RegisterFunc(func() (*Repository, error) {
+		return nil, errors.New("connection refused")
+	}, "")
+	expect(t, err.Error(), "connection refused")
+	expect(t, injector.Has(reflect.TypeOf(&Repository{}), ""), false)
+
+	err = injector.RegisterFunc(func() (*Repository, fmt.Stringer) {
+		return &Repository{}, nil
+	}, "")
+	expect(t, err.Error(), "Cannot register nil interface value returned by func() (*zinject_test.Repository, fmt.Stringer) (result 1)")
+	expect(t, injector.Has(reflect.TypeOf(&Repository{}), ""), false)
+
+	err = injector.RegisterFunc(func(int) *Repository { return &Repository{} }, "")
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+}
@@ -0,0 +1,60 @@
+package zinject
+
+import (
+	"reflect"
+	"strings"
+)
+
+// qualifierSeparator separates the key from the qualifier in a qualified
+// key, such as "db@primary".
+const qualifierSeparator = "@"
+
+// Maps val like Register, qualifying the mapping for the lookups made with a
+// qualified key.
+func (inj *injector) RegisterQualified(val interface{}, key string, qualifier string) Injector {
+	typ, v := reflect.TypeOf(val), reflect.ValueOf(val)
+	key = inj.keyOf(key)
+	checkValue(typ, v)
+	inj.checkOverride(typ, key)
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.setValue(typ, key, v)
+	if inj.qualifiers == nil {
+		inj.qualifiers = map[Registration]string{}
+	}
+	inj.qualifiers[Registration{Type: typ, Key: key}] = qualifier
+	return inj
+}
+
+// resolveQualified looks for the mapping qualified by the qualifier of the
+// qualified key key, under its key, whose type is t or implements the
+// interface type t. Returns an *AmbiguousError if several match.
+func (inj *injector) resolveQualified(t reflect.Type, key string, r *resolution) (reflect.Value, error) {
+	base, qualifier, ok := strings.Cut(key, qualifierSeparator)
+	if !ok {
+		return reflect.Value{}, nil
+	}
+	base = inj.keyOf(base)
+
+	inj.mu.RLock()
+	var candidates []reflect.Type
+	for _, reg := range inj.order {
+		if q, found := inj.qualifiers[reg]; !found || reg.Key != base || q != qualifier {
+			continue
+		}
+		if reg.Type == t || t.Kind() == reflect.Interface && reg.Type.Implements(t) {
+			candidates = append(candidates, reg.Type)
+		}
+	}
+	inj.mu.RUnlock()
+
+	switch len(candidates) {
+	case 0:
+		return reflect.Value{}, nil
+	case 1:
+		return inj.lookup(candidates[0], base, r)
+	default:
+		return reflect.Value{}, &AmbiguousError{Type: t, Key: key, Candidates: candidates}
+	}
+}
@@ -0,0 +1,73 @@
+package zinject_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+type QualifiedStruct struct {
+	Primary   fmt.Stringer `inject:"@primary"`
+	Secondary fmt.Stringer `inject:"@secondary"`
+	Greeter   *Greeter     `inject:"@primary,optional"`
+}
+
+func Test_InjectorQualified(t *testing.T) {
+	injector := zinject.New()
+	injector.RegisterQualified(&Greeter{"Jeremy"}, "", "primary")
+	injector.RegisterQualified(&Farewell{"Jeremy"}, "", "secondary")
+
+	stringerType := zinject.InterfaceOf((*fmt.Stringer)(nil))
+	_, err := injector.GetE(stringerType, "")
+	var ae *zinject.AmbiguousError
+	expect(t, errors.As(err, &ae), true)
+
+	s := QualifiedStruct{}
+	expect(t, injector.Inject(&s), nil)
+	expect(t, s.Primary.String(), "Hello, My name isJeremy")
+	expect(t, s.Secondary.String(), "Goodbye, Jeremy")
+	expect(t, s.Greeter.Name, "Jeremy")
+
+	// qualifiers are searched in the parents, under the key of the qualified key
+	child := injector.Child()
+	child.RegisterQualified(&Farewell{"Jeremy"}, "other", "primary")
+	expect(t, child.Get(stringerType, "@primary").Interface().(fmt.Stringer).String(), "Hello, My name isJeremy")
+	expect(t, child.Get(stringerType, "other@primary").Interface().(fmt.Stringer).String(), "Goodbye, Jeremy")
+	expect(t, child.Get(stringerType, "other@secondary").IsValid(), false)
+
+	// an exact mapping under the qualified key comes first
+	child.RegisterAs(&Farewell{"Exact"}, "@primary", (*fmt.Stringer)(nil))
+	expect(t, child.Get(stringerType, "@primary").Interface().(fmt.Stringer).String(), "Goodbye, Exact")
+
+	// mapping a value again without a qualifier drops it
+	injector.Register(&Greeter{"Jeremy"}, "")
+	expect(t, injector.Get(stringerType, "@primary").IsValid(), false)
+	expect(t, injector.Get(reflect.TypeOf(&Greeter{}), "@primary").IsValid(), false)
+}
+
+func Test_InjectorQualifiedAmbiguous(t *testing.T) {
+	injector := zinject.New()
+	injector.RegisterQualified(&Greeter{"Jeremy"}, "", "primary")
+	injector.RegisterQualified(&Farewell{"Jeremy"}, "", "primary")
+
+	s := QualifiedStruct{}
+	err := injector.Inject(&s)
+	var ae *zinject.AmbiguousError
+	expect(t, errors.As(err, &ae), true)
+	expect(t, ae.Key, "@primary")
+	expect(t, ae.Field, "Primary")
+}
+
+func Test_InjectorCopyToQualified(t *testing.T) {
+	injector := zinject.New()
+	injector.RegisterQualified(&Greeter{"Jeremy"}, "", "primary")
+
+	copied := zinject.New()
+	injector.CopyTo(copied)
+	stringerType := zinject.InterfaceOf((*fmt.Stringer)(nil))
+	expect(t, copied.Get(stringerType, "@primary").Interface().(fmt.Stringer).String(), "Hello, My name isJeremy")
+	expect(t, copied.Get(stringerType, "@secondary").IsValid(), false)
+}
@@ -0,0 +1,59 @@
+package zinject
+
+import "reflect"
+
+// Calls fn for every registration of the injector itself, in registration
+// order, until fn returns false. The Value passed to fn is invalid for
+// dependencies whose provider has not been called yet.
+func (inj *injector) Range(fn func(typ reflect.Type, key string, val reflect.Value) bool) {
+	inj.rangeLocal(nil, fn)
+}
+
+// Calls fn like Range for the registrations of the injector, then for the
+// ones of its parents that are not shadowed by a child.
+func (inj *injector) RangeAll(fn func(typ reflect.Type, key string, val reflect.Value) bool) {
+	seen := map[Registration]bool{}
+	visited := map[*injector]bool{}
+	var cur Injector = inj
+	for cur != nil {
+		i, ok := cur.(*injector)
+		if !ok {
+			cur.RangeAll(func(typ reflect.Type, key string, val reflect.Value) bool {
+				if seen[Registration{Type: typ, Key: key}] {
+					return true
+				}
+				return fn(typ, key, val)
+			})
+			return
+		}
+		if visited[i] || !i.rangeLocal(seen, fn) {
+			return
+		}
+		visited[i] = true
+		cur = i.Parent()
+	}
+}
+
+// rangeLocal calls fn for the registrations of the injector that are not
+// in seen, adding them to seen if it is not nil. It reports whether fn
+// returned true for all of them.
+func (inj *injector) rangeLocal(seen map[Registration]bool, fn func(typ reflect.Type, key string, val reflect.Value) bool) bool {
+	inj.mu.RLock()
+	bindings := make([]binding, 0, len(inj.order))
+	for _, reg := range inj.order {
+		if !seen[reg] {
+			bindings = append(bindings, binding{Registration: reg, val: inj.values[reg.Type][reg.Key]})
+		}
+	}
+	inj.mu.RUnlock()
+
+	for _, b := range bindings {
+		if seen != nil {
+			seen[b.Registration] = true
+		}
+		if !fn(b.Type, b.Key, b.val) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package zinject_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_InjectorRange(t *testing.T) {
+	parent := zinject.New()
+	parent.Register("parent dep", "").Register(11, "")
+
+	injector := parent.Child()
+	injector.Register("a dep", "").Register(&Greeter{"Jeremy"}, "greeter")
+	injector.Factory(func() *Database { return &Database{} }, "")
+
+	var visited []string
+	injector.Range(func(typ reflect.Type, key string, val reflect.Value) bool {
+		visited = append(visited, fmt.Sprintf("%v %q %v", typ, key, val.IsValid()))
+		return true
+	})
+	expect(t, strings.Join(visited, "; "), `string "" true; *zinject_test.Greeter "greeter" true; *zinject_test.Database "" false`)
+
+	visited = nil
+	injector.Range(func(typ reflect.Type, key string, val reflect.Value) bool {
+		visited = append(visited, typ.String())
+		return false
+	})
+	expect(t, len(visited), 1)
+}
+
+func Test_InjectorRangeAll(t *testing.T) {
+	parent := zinject.New()
+	parent.Register("parent dep", "").Register(11, "")
+
+	injector := parent.Child()
+	injector.Register("a dep", "").Register(&Greeter{"Jeremy"}, "greeter")
+
+	var visited []string
+	injector.RangeAll(func(typ reflect.Type, key string, val reflect.Value) bool {
+		visited = append(visited, fmt.Sprint(val))
+		return true
+	})
+	expect(t, strings.Join(visited, "; "), "a dep; Hello, My name isJeremy; 11")
+
+	visited = nil
+	injector.RangeAll(func(typ reflect.Type, key string, val reflect.Value) bool {
+		visited = append(visited, fmt.Sprint(val))
+		return len(visited) < 2
+	})
+	expect(t, len(visited), 2)
+}
@@ -0,0 +1,187 @@
+package zinject
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// scoped is a view of an Injector prefixing the keys of its mappings and
+// lookups. The methods not taking a key are those of the Injector itself.
+type scoped struct {
+	Injector
+	prefix string
+}
+
+// keySeparator separates the prefix of a scoped view from the key.
+const keySeparator = "/"
+
+// Returns a view of the injector that prepends the prefix and a slash to the
+// keys of the mappings and lookups made through it.
+func (inj *injector) Scoped(prefix string) Injector {
+	return &scoped{Injector: inj, prefix: prefix}
+}
+
+func (s *scoped) Scoped(prefix string) Injector {
+	return &scoped{Injector: s.Injector, prefix: s.key(prefix)}
+}
+
+// key returns key prefixed with the prefix of the view.
+func (s *scoped) key(key string) string {
+	return s.prefix + keySeparator + key
+}
+
+func (s *scoped) Register(val interface{}, key string) Injector {
+	s.Injector.Register(val, s.key(key))
+	return s
+}
+
+func (s *scoped) RegisterKeys(val interface{}, keys ...string) Injector {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = s.key(key)
+	}
+	s.Injector.RegisterKeys(val, prefixed...)
+	return s
+}
+
+func (s *scoped) RegisterDefault(val interface{}, key string) Injector {
+	s.Injector.RegisterDefault(val, s.key(key))
+	return s
+}
+
+func (s *scoped) RegisterType(typ reflect.Type, val interface{}, key string) Injector {
+	s.Injector.RegisterType(typ, val, s.key(key))
+	return s
+}
+
+func (s *scoped) RegisterTTL(val interface{}, key string, ttl time.Duration) Injector {
+	s.Injector.RegisterTTL(val, s.key(key), ttl)
+	return s
+}
+
+func (s *scoped) RegisterFunc(fn interface{}, key string) error {
+	return s.Injector.RegisterFunc(fn, s.key(key))
+}
+
+func (s *scoped) AutoRegister(val interface{}, key string, flags AutoRegisterFlags) Injector {
+	s.Injector.AutoRegister(val, s.key(key), flags)
+	return s
+}
+
+func (s *scoped) RegisterAs(val interface{}, key string, ifacePtr interface{}) Injector {
+	s.Injector.RegisterAs(val, s.key(key), ifacePtr)
+	return s
+}
+
+func (s *scoped) RegisterQualified(val interface{}, key string, qualifier string) Injector {
+	s.Injector.RegisterQualified(val, s.key(key), qualifier)
+	return s
+}
+
+func (s *scoped) RegisterNil(typ reflect.Type, key string) Injector {
+	s.Injector.RegisterNil(typ, s.key(key))
+	return s
+}
+
+func (s *scoped) Set(typ reflect.Type, key string, val reflect.Value) Injector {
+	s.Injector.Set(typ, s.key(key), val)
+	return s
+}
+
+func (s *scoped) SetBoth(typ reflect.Type, key string, val reflect.Value) Injector {
+	s.Injector.SetBoth(typ, s.key(key), val)
+	return s
+}
+
+func (s *scoped) SetMap(m map[reflect.Type]reflect.Value, key string, skipInvalid bool) Injector {
+	s.Injector.SetMap(m, s.key(key), skipInvalid)
+	return s
+}
+
+func (s *scoped) SetKeys(typ reflect.Type, val reflect.Value, keys ...string) Injector {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = s.key(key)
+	}
+	s.Injector.SetKeys(typ, val, prefixed...)
+	return s
+}
+
+func (s *scoped) Factory(fn interface{}, key string) Injector {
+	s.Injector.Factory(fn, s.key(key))
+	return s
+}
+
+func (s *scoped) ProvideConstructor(fn interface{}, key string) Injector {
+	s.Injector.ProvideConstructor(fn, s.key(key))
+	return s
+}
+
+func (s *scoped) Provider(fn interface{}, key string) Injector {
+	s.Injector.Provider(fn, s.key(key))
+	return s
+}
+
+func (s *scoped) ProvideTransient(fn interface{}, key string) Injector {
+	s.Injector.ProvideTransient(fn, s.key(key))
+	return s
+}
+
+func (s *scoped) Unregister(typ reflect.Type, key string) bool {
+	return s.Injector.Unregister(typ, s.key(key))
+}
+
+// RemoveType removes the mappings of typ within the view only.
+func (s *scoped) RemoveType(typ reflect.Type) int {
+	n := 0
+	for _, key := range s.Keys(typ) {
+		if s.Unregister(typ, key) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *scoped) Get(typ reflect.Type, key string) reflect.Value {
+	return s.Injector.Get(typ, s.key(key))
+}
+
+func (s *scoped) GetE(typ reflect.Type, key string) (reflect.Value, error) {
+	return s.Injector.GetE(typ, s.key(key))
+}
+
+func (s *scoped) MustGet(typ reflect.Type, key string) reflect.Value {
+	return s.Injector.MustGet(typ, s.key(key))
+}
+
+func (s *scoped) GetAndInject(typ reflect.Type, key string) (reflect.Value, error) {
+	return s.Injector.GetAndInject(typ, s.key(key))
+}
+
+func (s *scoped) Has(typ reflect.Type, key string) bool {
+	return s.Injector.Has(typ, s.key(key))
+}
+
+func (s *scoped) HasLocal(typ reflect.Type, key string) bool {
+	return s.Injector.HasLocal(typ, s.key(key))
+}
+
+func (s *scoped) Locate(typ reflect.Type, key string) (Injector, bool) {
+	return s.Injector.Locate(typ, s.key(key))
+}
+
+func (s *scoped) GetOrRegister(typ reflect.Type, key string, create func() reflect.Value) reflect.Value {
+	return s.Injector.GetOrRegister(typ, s.key(key), create)
+}
+
+// Keys returns the keys of typ within the view, without their prefix.
+func (s *scoped) Keys(typ reflect.Type) []string {
+	keys := []string{}
+	for _, key := range s.Injector.Keys(typ) {
+		if k, ok := strings.CutPrefix(key, s.prefix+keySeparator); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
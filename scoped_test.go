@@ -0,0 +1,48 @@
+package zinject_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_InjectorScoped(t *testing.T) {
+	injector := zinject.New()
+	auth := injector.Scoped("auth")
+	billing := injector.Scoped("billing")
+
+	auth.Register("auth token", "token").Register("auth default", "")
+	billing.Register("billing token", "token")
+
+	stringType := reflect.TypeOf("string")
+	expect(t, auth.Get(stringType, "token").String(), "auth token")
+	expect(t, billing.Get(stringType, "token").String(), "billing token")
+	expect(t, auth.Get(stringType, "").String(), "auth default")
+	expect(t, billing.Has(stringType, ""), false)
+	expect(t, injector.Get(stringType, "auth/token").String(), "auth token")
+	expect(t, injector.Has(stringType, "token"), false)
+	expect(t, fmt.Sprint(billing.Keys(stringType)), "[token]")
+
+	token, ok := zinject.Get[string](billing, "token")
+	expect(t, ok, true)
+	expect(t, token, "billing token")
+
+	nested := auth.Scoped("oauth")
+	nested.Factory(func() string { return "oauth token" }, "token")
+	expect(t, injector.Get(stringType, "auth/oauth/token").String(), "oauth token")
+
+	expect(t, billing.Unregister(stringType, "token"), true)
+	expect(t, auth.Get(stringType, "token").String(), "auth token")
+
+	auth.SetMap(map[reflect.Type]reflect.Value{reflect.TypeOf(11): reflect.ValueOf(42)}, "token", false)
+	expect(t, injector.Get(reflect.TypeOf(11), "auth/token").Int(), int64(42))
+	expect(t, injector.Has(reflect.TypeOf(11), "token"), false)
+	expect(t, auth.RemoveType(reflect.TypeOf(11)), 1)
+
+	billing.Register("billing default", "")
+	expect(t, billing.RemoveType(stringType), 1)
+	expect(t, auth.RemoveType(stringType), 3)
+	expect(t, injector.Len(), 0)
+}
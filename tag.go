@@ -0,0 +1,224 @@
+package zinject
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// InjectTag is the parsed form of an 'inject' struct tag, as used by Inject.
+// The first comma separated segment is the key, the following segments are
+// options, spaces around them being ignored:
+//
+//	optional      the field is left alone if nothing is mapped to it
+//	zero          the field is reset to its zero value, the key is not looked up
+//	default=LIT   the field is set to LIT if nothing is mapped to it
+//	priority=N    the fields of higher priority are injected first
+//
+// For example:
+//
+//	`inject:"primary,optional"`
+//	`inject:"port,default=8080"`
+//	`inject:",zero"`
+//	`inject:"db,priority=10"`
+type InjectTag struct {
+	Key      string
+	Optional bool
+	Zero     bool
+	// HasDefault tells an empty default apart from no default.
+	HasDefault bool
+	Default    string
+	Priority   int
+}
+
+// ParseInjectTag parses the value of an 'inject' struct tag. Returns an error
+// if an option is empty, unknown, repeated, or has an invalid value.
+func ParseInjectTag(tag string) (InjectTag, error) {
+	parts := strings.Split(tag, ",")
+	t := InjectTag{Key: parts[0]}
+	seen := map[string]bool{}
+	for _, option := range parts[1:] {
+		option = strings.TrimSpace(option)
+		name, value, hasValue := strings.Cut(option, "=")
+		if seen[name] {
+			return InjectTag{}, fmt.Errorf("invalid inject tag %q: repeated option %q", tag, name)
+		}
+		seen[name] = true
+		switch {
+		case option == "":
+			return InjectTag{}, fmt.Errorf("invalid inject tag %q: empty option", tag)
+		case option == "optional":
+			t.Optional = true
+		case option == "zero":
+			t.Zero = true
+		case name == "default" && hasValue:
+			t.HasDefault = true
+			t.Default = value
+		case name == "priority" && hasValue:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return InjectTag{}, fmt.Errorf("invalid inject tag %q: invalid priority %q", tag, value)
+			}
+			t.Priority = n
+		default:
+			return InjectTag{}, fmt.Errorf("invalid inject tag %q: unknown option %q", tag, option)
+		}
+	}
+	return t, nil
+}
+
+// parseDefault converts the default literal lit to a Value of type t, which
+// must be of a string, integer, boolean or floating-point kind.
+func parseDefault(lit string, t reflect.Type) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(lit)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(lit, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(lit, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(lit)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(lit, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetFloat(f)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported kind %v", t.Kind())
+	}
+	return v, nil
+}
+
+// fieldInfo is the cached metadata of a struct field.
+type fieldInfo struct {
+	index    int
+	name     string
+	embedded bool
+	tagged   bool
+	tag      InjectTag
+	// tagErr is the error of a malformed tag.
+	tagErr error
+}
+
+// defaultTagName is the name of the struct tag read by Inject by default.
+const defaultTagName = "inject"
+
+func (inj *injector) SetTagName(name string) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.tagName = name
+}
+
+func (inj *injector) SetKeyFromTag(name string) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.keyTag = name
+}
+
+// tagsOf returns the name of the struct tag read by the injector, and the
+// name of the tag empty keys are derived from.
+func (inj *injector) tagsOf() (tagName, keyTag string) {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	if inj.tagName == "" {
+		return defaultTagName, inj.keyTag
+	}
+	return inj.tagName, inj.keyTag
+}
+
+// keyFromTag returns the name in the tag named keyTag of tag, without its
+// options, or "" if there is none or it is "-".
+func keyFromTag(tag reflect.StructTag, keyTag string) string {
+	name, _, _ := strings.Cut(tag.Get(keyTag), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// fieldKey identifies the fields of a struct type read with a tag name.
+type fieldKey struct {
+	typ reflect.Type
+	tag string
+}
+
+// fieldCache maps a fieldKey to its []fieldInfo.
+var fieldCache sync.Map
+
+// fieldsOf returns the metadata of the fields of the struct type t, parsing
+// their tag named tagName only the first time t is seen with it. The fields
+// are sorted by descending priority, fields of equal priority keeping their
+// declaration order.
+func fieldsOf(t reflect.Type, tagName string) []fieldInfo {
+	fk := fieldKey{t, tagName}
+	if fields, ok := fieldCache.Load(fk); ok {
+		return fields.([]fieldInfo)
+	}
+
+	fields := make([]fieldInfo, t.NumField())
+	for i := range fields {
+		sf := t.Field(i)
+		fields[i].index = i
+		fields[i].name = sf.Name
+		fields[i].embedded = sf.Anonymous
+		if tag, found := sf.Tag.Lookup(tagName); found {
+			fields[i].tagged = true
+			fields[i].tag, fields[i].tagErr = ParseInjectTag(tag)
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].tag.Priority > fields[j].tag.Priority
+	})
+
+	actual, _ := fieldCache.LoadOrStore(fk, fields)
+	return actual.([]fieldInfo)
+}
+
+// injectable reports whether the struct type t has fields tagged with
+// tagName, directly or through its embedded structs. seen guards against
+// recursive types.
+func injectable(t reflect.Type, tagName string, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	if seen == nil {
+		seen = map[reflect.Type]bool{}
+	}
+	seen[t] = true
+	for _, fi := range fieldsOf(t, tagName) {
+		if fi.tagged {
+			return true
+		}
+		if fi.embedded {
+			ft := t.Field(fi.index).Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && injectable(ft, tagName, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package zinject_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_ParseInjectTag(t *testing.T) {
+	tag, err := zinject.ParseInjectTag("port, optional ,default=8080,priority=-2")
+	expect(t, err, nil)
+	expect(t, tag, zinject.InjectTag{Key: "port", Optional: true, HasDefault: true, Default: "8080", Priority: -2})
+
+	tag, err = zinject.ParseInjectTag(",zero")
+	expect(t, err, nil)
+	expect(t, tag, zinject.InjectTag{Zero: true})
+
+	tag, err = zinject.ParseInjectTag("name,default=")
+	expect(t, err, nil)
+	expect(t, tag, zinject.InjectTag{Key: "name", HasDefault: true})
+
+	for tag, msg := range map[string]string{
+		"port,":                    `invalid inject tag "port,": empty option`,
+		"port,required":            `invalid inject tag "port,required": unknown option "required"`,
+		"port,optional=yes":        `invalid inject tag "port,optional=yes": unknown option "optional=yes"`,
+		"port,priority=max":        `invalid inject tag "port,priority=max": invalid priority "max"`,
+		"port,default=1,default=2": `invalid inject tag "port,default=1,default=2": repeated option "default"`,
+	} {
+		_, err := zinject.ParseInjectTag(tag)
+		refute(t, err, nil)
+		expect(t, err.Error(), msg)
+	}
+}
+
+type MalformedTagStruct struct {
+	Name string `inject:"name,required"`
+	Port int    `inject:"port,optional"`
+}
+
+func Test_InjectorMalformedTag(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "name").Register(8080, "port")
+
+	s := MalformedTagStruct{}
+	err := injector.Inject(&s)
+	refute(t, err, nil)
+	expect(t, err.Error(), `inject: MalformedTagStruct.Name: invalid inject tag "name,required": unknown option "required"`)
+
+	err = injector.InjectAllErrors(&s)
+	expect(t, strings.Count(err.Error(), "invalid inject tag"), 1)
+	expect(t, s.Port, 8080)
+}
@@ -0,0 +1,46 @@
+package zinject
+
+import (
+	"reflect"
+	"time"
+)
+
+// Maps val like Register, the mapping expiring once ttl has elapsed.
+func (inj *injector) RegisterTTL(val interface{}, key string, ttl time.Duration) Injector {
+	typ, v := reflect.TypeOf(val), reflect.ValueOf(val)
+	key = inj.keyOf(key)
+	checkValue(typ, v)
+	inj.checkOverride(typ, key)
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.setValue(typ, key, v)
+	if inj.expiries == nil {
+		inj.expiries = map[Registration]time.Time{}
+	}
+	inj.expiries[Registration{Type: typ, Key: key}] = time.Now().Add(ttl)
+	return inj
+}
+
+func (inj *injector) Prune() int {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	// expired mappings are gone whether the injector is frozen or not
+	now := time.Now()
+	n := 0
+	for reg, at := range inj.expiries {
+		if now.After(at) {
+			inj.unregister(reg.Type, reg.Key)
+			n++
+		}
+	}
+	return n
+}
+
+// expired reports whether the mapping of t and key has expired.
+// The caller must hold inj.mu.
+func (inj *injector) expired(t reflect.Type, key string) bool {
+	at, found := inj.expiries[Registration{Type: t, Key: key}]
+	return found && time.Now().After(at)
+}
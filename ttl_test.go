@@ -0,0 +1,89 @@
+package zinject_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zionkit/zinject"
+)
+
+func Test_InjectorRegisterTTL(t *testing.T) {
+	parent := zinject.New()
+	parent.Register("parent dep", "")
+
+	injector := parent.Child()
+	injector.RegisterTTL("session dep", "", time.Millisecond)
+	injector.RegisterTTL(11, "", time.Hour)
+
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "session dep")
+	expect(t, injector.HasLocal(reflect.TypeOf("string"), ""), true)
+
+	time.Sleep(5 * time.Millisecond)
+	expect(t, injector.HasLocal(reflect.TypeOf("string"), ""), false)
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "parent dep")
+	expect(t, len(injector.Registrations()), 1)
+	expect(t, injector.Get(reflect.TypeOf(11), "").Int(), int64(11))
+
+	// registering again replaces the expiry
+	injector.RegisterTTL("session dep", "", time.Millisecond)
+	injector.Register("permanent dep", "")
+	time.Sleep(5 * time.Millisecond)
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "permanent dep")
+}
+
+func Test_InjectorPrune(t *testing.T) {
+	injector := zinject.New()
+	injector.RegisterTTL("a dep", "", time.Millisecond)
+	injector.RegisterTTL("another dep", "other", time.Millisecond)
+	injector.RegisterTTL(11, "", time.Hour)
+	injector.Register(1.5, "")
+
+	expect(t, injector.Prune(), 0)
+	time.Sleep(5 * time.Millisecond)
+	expect(t, len(injector.Registrations()), 4)
+	expect(t, injector.Prune(), 2)
+	expect(t, len(injector.Registrations()), 2)
+	expect(t, injector.Prune(), 0)
+}
+
+func Test_InjectorPruneFrozen(t *testing.T) {
+	injector := zinject.New()
+	injector.RegisterTTL("a dep", "", time.Millisecond)
+	injector.RegisterTTL("another dep", "other", time.Millisecond)
+	injector.Freeze()
+
+	expect(t, injector.Prune(), 0)
+	time.Sleep(5 * time.Millisecond)
+	expect(t, injector.Get(reflect.TypeOf("string"), "").IsValid(), false)
+	expect(t, injector.Prune(), 1)
+	expect(t, injector.Len(), 0)
+}
+
+func Test_InjectorCopyToTTL(t *testing.T) {
+	injector := zinject.New()
+	injector.RegisterTTL("expired dep", "", time.Millisecond)
+	injector.RegisterTTL(11, "", 20*time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	copied := zinject.New()
+	injector.CopyTo(copied)
+	expect(t, copied.HasLocal(reflect.TypeOf("string"), ""), false)
+	expect(t, copied.Get(reflect.TypeOf(11), "").Int(), int64(11))
+
+	time.Sleep(20 * time.Millisecond)
+	expect(t, copied.HasLocal(reflect.TypeOf(11), ""), false)
+}
+
+func Test_InjectorRegisterDefaultExpired(t *testing.T) {
+	injector := zinject.New()
+	injector.RegisterTTL("session dep", "", time.Millisecond)
+	injector.RegisterDefault("default", "")
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "session dep")
+
+	time.Sleep(5 * time.Millisecond)
+	injector.RegisterDefault("default", "")
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "default")
+	time.Sleep(5 * time.Millisecond)
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "default")
+}
@@ -2,161 +2,2116 @@
 package zinject
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
 // Injector represents an interface for mapping and injecting dependencies into structs
 // and function arguments.
 type Injector interface {
 	// Maps dependencies in the Type map to each field in the struct
-	// that is tagged with 'inject'. Returns an error if the injection
-	// fails.
+	// that is tagged with 'inject'. The tag value is used as the key of
+	// the dependency, `inject:""` resolves the default "" key while
+	// `inject:"primary"` resolves the value registered under "primary".
+	// Fields tagged with the optional flag, `inject:"primary,optional"`,
+	// are left untouched when no value is found, while fields tagged with a
+	// default value, `inject:"port,default=8080"`, are set to it. Fields
+	// tagged with the zero flag, `inject:",zero"`, are reset to their zero
+	// value, whatever is mapped to their key, which lets reused structs be
+	// sanitized.
+	// When no value is mapped to the exact type of a field, a value of the
+	// same key that is assignable or convertible to it is used instead.
+	// Slice fields tagged with the "*" key, `inject:"*"`, receive every mapped
+	// value assignable to their element type, in registration order, array
+	// fields receive as many of them as they can hold, and
+	// map fields with string keys receive them by registration key, the
+	// default "" key included. The fields of embedded structs are injected
+	// as well, nil embedded pointers being allocated when needed. Exported
+	// methods prefixed with "Inject", like InjectLogger(Logger), are then
+	// called with their arguments resolved from the injector, and their
+	// error, if they return one, is returned.
+	// Returns an error if the injection fails, or if the value provided is
+	// neither a struct nor a non-nil pointer to struct.
 	Inject(interface{}) error
 
+	// Maps dependencies like Inject, additionally walking into nested struct
+	// and pointer to struct fields, and into the elements of slice, array and
+	// map fields. Struct values of maps, which are not addressable, are
+	// injected into a copy stored back in the map. Returns an error if the
+	// injection fails.
+	InjectDeep(interface{}) error
+
+	// Maps dependencies like Inject, including unexported fields tagged with
+	// 'inject'. Returns an error if the injection fails.
+	InjectUnexported(interface{}) error
+
+	// Maps dependencies like Inject, additionally setting the exported
+	// untagged fields: each one receives the value mapped to its type under
+	// its lowercased name, `Primary string` resolving the "primary" key, or
+	// under the default "" key. Tags take precedence over names, tagged
+	// fields being injected like Inject does, and untagged fields nothing is
+	// mapped for are left untouched.
+	InjectByName(interface{}) error
+
+	// Maps dependencies like Inject, attempting every field and method
+	// instead of stopping at the first failure. The fields resolved are set
+	// and all the errors are returned joined. Init is only called if there
+	// were none.
+	InjectAllErrors(interface{}) error
+
+	// Reports whether Inject would succeed, performing the same lookups
+	// without setting any field nor calling any method, provider or decoder:
+	// providers are only checked to have their own dependencies. Every
+	// failure is returned, joined like InjectAllErrors does.
+	CanInject(interface{}) error
+
+	// Maps dependencies like Inject into each of the values provided. Slices
+	// and arrays, or pointers to them, have each of their elements injected
+	// instead. Every value is injected even if some of them fail, and the
+	// errors are returned joined, each one noting the argument and element
+	// it comes from.
+	InjectAll(...interface{}) error
+
+	// Maps dependencies like Inject into a copy of the struct provided, which
+	// is returned. The struct provided is never modified.
+	InjectValue(interface{}) (interface{}, error)
+
+	// Invoke attempts to call the interface{} provided as a function,
+	// providing dependencies for function arguments based on Type.
+	// Returns a slice of reflect.Value representing the returned values
+	// of the function. Returns an error if the injection fails.
+	Invoke(interface{}) ([]reflect.Value, error)
+
+	// Invoke the function like Invoke, supplying the given context to every
+	// argument of type context.Context, all of them receiving the same one.
+	InvokeCtx(context.Context, interface{}) ([]reflect.Value, error)
+
+	// Invoke the function like Invoke, passing the given overrides instead of
+	// resolving the arguments they are assignable to, for this call only. The
+	// first override assignable to an argument is used. Returns an error if
+	// an override is assignable to none of the arguments.
+	InvokeWith(interface{}, ...interface{}) ([]reflect.Value, error)
+
+	// Invoke the function like Invoke, the function returning either a value
+	// and an error or only an error. Returns the value, nil if the function
+	// only returns an error, and the error returned by the function or by
+	// the injection. Returns an error if the function has another signature.
+	InvokeE(interface{}) (interface{}, error)
+
 	// Maps the interface{} value based on its immediate type from reflect.TypeOf.
 	Register(interface{}, string) Injector
 
+	// Maps the interface{} value based on its immediate type from reflect.TypeOf
+	// under each of the given keys.
+	RegisterKeys(interface{}, ...string) Injector
+
+	// Maps the interface{} value based on its immediate type from reflect.TypeOf,
+	// unless a value or provider is already mapped to that type and key in the
+	// injector itself. This lets modules provide defaults which are kept only
+	// if nothing was registered before them.
+	RegisterDefault(interface{}, string) Injector
+
+	// Maps the interface{} value to the given reflect.Type, which it must be
+	// assignable to. A nil value maps the nil value of the type, which must
+	// then be a pointer, interface, map, slice, channel or function type.
+	RegisterType(reflect.Type, interface{}, string) Injector
+
+	// Maps the interface{} value based on its immediate type from reflect.TypeOf
+	// for the given duration. Once it has elapsed, the mapping is ignored by
+	// Get, Has and Inject, which fall back to the parents, and is removed the
+	// next time it is looked up or when Prune is called. Registering the type
+	// and key again replaces the mapping along with its expiry. Expiry is
+	// checked when the mapping is looked up, Values obtained before it stay
+	// valid for their holders.
+	RegisterTTL(interface{}, string, time.Duration) Injector
+
+	// Removes the expired mappings registered with RegisterTTL from the
+	// injector, ignoring its parents, and returns how many were removed.
+	// Expired mappings are removed from frozen injectors as well, like the
+	// lookups do.
+	Prune() int
+
+	// Calls the function provided like Invoke and maps each of its return
+	// values but a trailing error, based on their dynamic type, under the
+	// given key. If the function returns a non-nil error, or a nil interface
+	// value, nothing is mapped and the error is returned.
+	RegisterFunc(interface{}, string) error
+
+	// Maps the value of each exported field of the struct provided, or of the
+	// struct it points to, under the given key. Fields are mapped to their
+	// declared type unless the DynamicType flag is set, and zero fields are
+	// skipped if the SkipZero flag is. Fields mapped to the same type replace
+	// each other, the last one winning. Panics if the value is not a struct.
+	AutoRegister(interface{}, string, AutoRegisterFlags) Injector
+
 	// Maps the interface{} value based on the pointer of an Interface provided.
 	// This is really only useful for mapping a value as an interface, as interfaces
 	// cannot at this time be referenced directly without a pointer.
 	RegisterAs(interface{}, string, interface{}) Injector
 
+	// Maps the interface{} value like Register, with a qualifier. A qualified
+	// key, such as "db@primary" or "@primary" for the default key, resolves
+	// to the value mapped to the requested type under that exact key if
+	// any, and otherwise to the value qualified so under the key whose type
+	// is the requested type or implements the requested interface, which
+	// tells apart several implementors mapped under the same key. Values
+	// mapped again without a qualifier lose it.
+	RegisterQualified(interface{}, string, string) Injector
+
+	// Maps the given type to its nil value, marking the dependency as present
+	// but intentionally empty: Has reports it, Get returns a valid Value whose
+	// IsNil is true, unlike the zero Value of a miss, and Inject sets the
+	// field to nil without error. Panics if values of the type cannot be nil.
+	RegisterNil(reflect.Type, string) Injector
+
 	// Provides a possibility to directly insert a mapping based on type and value.
 	// This makes it possible to directly map type arguments not possible to instantiate
-	// with reflect like unidirectional channels.
+	// with reflect like unidirectional channels. Panics if the Value is invalid
+	// or not assignable to the Type. Set is the fast path for values mapped
+	// over and over, such as request-scoped ones: unlike Register, it does not
+	// box non-pointer values into an interface{} on each call.
 	Set(reflect.Type, string, reflect.Value) Injector
 
+	// Maps each Value of the map to its Type like Set, under the given key,
+	// in the order of the type names. Invalid Values are skipped if the flag
+	// is set, and otherwise cause a panic before anything is mapped.
+	SetMap(map[reflect.Type]reflect.Value, string, bool) Injector
+
+	// Maps the Value to the Type like Set and, if the Type is an interface,
+	// to the concrete type of the Value as well, under the same key, so that
+	// it can be looked up both ways.
+	SetBoth(reflect.Type, string, reflect.Value) Injector
+
+	// Maps the Type to the Value like Set, under each of the given keys.
+	SetKeys(reflect.Type, reflect.Value, ...string) Injector
+
+	// Maps the result of the function provided to its first return type. The
+	// function is called the first time the dependency is requested and its
+	// result is reused afterwards. It may accept the Injector as its only
+	// argument and return an error as its second value, which is surfaced by
+	// GetE and Inject. Requesting its own type through that Injector, be it
+	// by a lookup, an injection or an invocation, fails with a *CycleError.
+	// Panics if the function does not have such a signature.
+	Factory(interface{}, string) Injector
+
+	// Maps the result of the constructor function provided to its first return
+	// type. The arguments of the constructor are resolved from the injector the
+	// first time the dependency is requested, and its result is reused
+	// afterwards. It may return an error as its second value. Panics if the
+	// function does not have such a signature.
+	ProvideConstructor(interface{}, string) Injector
+
+	// Maps the result of the provider function provided to its first return
+	// type. The function takes the Injector as its only argument and may
+	// return an error as its second value, surfaced by GetE and Inject. It is
+	// called each time the dependency is requested, with the injector the
+	// request was made to, which may be a child of the one it was mapped in.
+	// Requesting its own type through that Injector, be it by a lookup, an
+	// injection or an invocation, fails with a *CycleError. Panics if the
+	// function does not have such a signature.
+	Provider(interface{}, string) Injector
+
+	// Maps the result of the constructor function provided to its first return
+	// type like ProvideConstructor, but calls the constructor each time the
+	// dependency is requested, its result never being reused. The arguments
+	// are resolved from the injector the request was made to, so that a
+	// constructor mapped in a parent sees the mappings of the child it is
+	// requested from. Panics if the function does not have such a signature.
+	ProvideTransient(interface{}, string) Injector
+
+	// Removes the mapping of the given type and key. Returns true if a mapping
+	// was removed.
+	Unregister(reflect.Type, string) bool
+
+	// Removes the mappings of the given type under every key, leaving the
+	// parent untouched. Returns the number of mappings removed.
+	RemoveType(reflect.Type) int
+
+	// Clear removes every mapping of the injector, the parent is left untouched.
+	Clear()
+
+	// ClearAll removes every mapping of the injector and detaches its parent.
+	ClearAll()
+
 	// Returns the Value that is mapped to the current type. Returns a zeroed Value if
-	// the Type has not been mapped.
+	// the Type has not been mapped, or if it is an interface that is implemented
+	// by several mapped types holding distinct values, interface types
+	// counting as implementors of the narrower interfaces. Directional channel
+	// types resolve to the bidirectional channel of the same element type if
+	// none is mapped to them. The Injector type resolves to the injector itself
+	// under the default key, unless an Injector is mapped to it, so that
+	// `inject:""` fields and arguments of type Injector receive it.
 	Get(reflect.Type, string) reflect.Value
 
+	// Reports whether a Value is mapped directly to the given type and key in
+	// the injector or one of its parents. Unlike Get, it does not search for
+	// implementors of interface types.
+	Has(reflect.Type, string) bool
+
+	// Reports whether a Value is mapped directly to the given type and key in
+	// the injector itself, ignoring its parents.
+	HasLocal(reflect.Type, string) bool
+
+	// Returns the injector holding the mapping of the given type and key, the
+	// injector itself or the nearest of its parents, and false if none does.
+	// Like Has, only the exact type and key are looked for, implementors of
+	// interface types are not.
+	Locate(reflect.Type, string) (Injector, bool)
+
+	// Returns the Value that is mapped to the current type, or maps the Value
+	// returned by the given function to it if there is none. The lookup and
+	// the registration are atomic.
+	GetOrRegister(reflect.Type, string, func() reflect.Value) reflect.Value
+
+	// Returns the Value that is mapped to the current type. Returns an error
+	// if the Type has not been mapped or its factory failed: a
+	// *NotFoundError, an *AmbiguousError when several mapped types implement
+	// the requested interface, a *CycleError, or a *ConversionError when the
+	// numeric fallback only finds values overflowing the type.
+	GetE(reflect.Type, string) (reflect.Value, error)
+
+	// Returns the Value that is mapped to the current type. Panics if the
+	// Type has not been mapped.
+	MustGet(reflect.Type, string) reflect.Value
+
+	// Returns the Value that is mapped to the current type like GetE, then
+	// injects it if it is a struct or a non-nil pointer to a struct. A struct
+	// is injected and returned as a copy, leaving the mapped value untouched.
+	// The value is injected on each call, so the tagged fields of a shared
+	// pointer are reset to their current mappings.
+	GetAndInject(reflect.Type, string) (reflect.Value, error)
+
+	// Returns every Value, under any key, whose type implements the given
+	// interface type, or is assignable to the given type, in the injector
+	// then in its parents. Values are ordered by registration within each
+	// injector, the ones of the injector coming before those of its parents,
+	// and mappings of a parent shadowed by the same type and key are left
+	// out. Providers are called if needed, the ones failing being skipped.
+	ResolveAll(reflect.Type) []reflect.Value
+
+	// Returns the number of mappings of the injector, every type and key pair
+	// counting once, ignoring its parents.
+	Len() int
+
+	// Reports whether the injector itself has no mapping.
+	IsEmpty() bool
+
+	// Returns the number of mappings of the injector and of its parents,
+	// mappings shadowed by a child being counted in each injector.
+	TotalLen() int
+
+	// Returns the type and key of every mapping of the injector, ignoring its
+	// parents, in the order they were added.
+	Registrations() []Registration
+
+	// Calls the function for every mapping of the injector itself, in the order
+	// they were added, stopping as soon as it returns false.
+	Range(func(reflect.Type, string, reflect.Value) bool)
+
+	// Calls the function like Range for the mappings of the injector, then for
+	// the mappings of its parents that are not shadowed.
+	RangeAll(func(reflect.Type, string, reflect.Value) bool)
+
+	// Returns the sorted keys mapped to the given type in the injector itself,
+	// ignoring its parents.
+	Keys(reflect.Type) []string
+
+	// Closes every value mapped in the injector itself that implements
+	// io.Closer, in reverse registration order. Returns the joined errors of
+	// the failed closes.
+	Close() error
+
+	// Validate checks that the dependencies of every provider of the injector
+	// itself can be resolved, without calling any of them. Returns the joined
+	// errors of every provider that cannot be resolved.
+	Validate() error
+
+	// Returns a human readable description of the mappings of the injector
+	// and of its parents, intended for debugging.
+	Dump() string
+
+	// Returns the mappings of the injector and of its parents as a Graphviz
+	// DOT digraph, with edges from the constructor providers not called yet
+	// to the mappings they depend on.
+	GraphDOT() string
+
 	// SetParent sets the parent of the injector. If the injector cannot find a
 	// dependency in its Type map it will check its parent before returning an
 	// error.
 	SetParent(Injector)
+
+	// Parent returns the parent of the injector, nil if it has none.
+	Parent() Injector
+
+	// Child returns a new Injector whose parent is the injector. Mappings of
+	// the child shadow the ones of its parent, which is never modified.
+	Child() Injector
+
+	// SetOverridePolicy sets how registrations of a type and key already
+	// mapped in the injector or one of its parents are handled. Children
+	// inherit the policy of the injector they are created from.
+	SetOverridePolicy(OverridePolicy)
+
+	// SetImplementorPolicy sets how an interface is resolved when several
+	// types mapped under the requested key implement it, in the order they
+	// were first mapped in, see Registrations. Mapping a type again under the
+	// same key does not change its position. Children inherit the policy of
+	// the injector they are created from.
+	SetImplementorPolicy(ImplementorPolicy)
+
+	// SetDefaultKey sets the key that the empty key stands for, in
+	// registrations, lookups and 'inject' tags alike. Children inherit the
+	// default key of the injector they are created from.
+	SetDefaultKey(string)
+
+	// SetNumericFallback sets whether Get, GetE and MustGet, when nothing is
+	// mapped to a numeric type, fall back to a value registered under the
+	// same key that converts to it without overflowing, such as an int for
+	// an int64. Disabled by default, children inherit the setting of the
+	// injector they are created from.
+	SetNumericFallback(bool)
+
+	// SetTagName sets the name of the struct tag read by Inject and its
+	// variants, "inject" by default or when set to "". Children inherit the
+	// tag name of the injector they are created from.
+	SetTagName(string)
+
+	// SetKeyFromTag sets the name of a struct tag, such as "json", whose
+	// value gives the key of the fields whose 'inject' tag has an empty key,
+	// options like ",omitempty" being stripped. Fields without such a tag, or
+	// with the "-" name, keep the default "" key. Disabled by default or when
+	// set to "", children inherit the setting of the injector they are
+	// created from.
+	SetKeyFromTag(string)
+
+	// SetPointerAdapt sets whether Inject adapts pointers when nothing is
+	// mapped to the type of a field: a *T field then receives a pointer to
+	// a copy of a mapped T, and a T field a copy of the value a mapped *T
+	// points to. Disabled by default, children inherit the setting of the
+	// injector they are created from.
+	SetPointerAdapt(bool)
+
+	// SetStringDecoding sets whether Inject decodes the string mapped under
+	// the key of a field when nothing is mapped to the type of the field and
+	// a decoder is registered for it. Durations and RFC 3339 times are
+	// decoded out of the box. Disabled by default, children inherit the
+	// setting and the decoders of the injector they are created from.
+	SetStringDecoding(bool)
+
+	// RegisterDecoder registers the decoder used by the string decoding for
+	// the given type, replacing the built-in one if any. Panics if the type
+	// or the decoder is nil.
+	RegisterDecoder(reflect.Type, Decoder)
+
+	// SetObserver sets a function called after each lookup made by Get, GetE
+	// and MustGet with the type and key looked up, whether a value was found
+	// and how long the lookup took. The function must be safe for concurrent
+	// use, and nil, the default, removes it. Children inherit the observer of
+	// the injector they are created from.
+	SetObserver(Observer)
+
+	// Freeze makes the mappings and the parent of the injector read-only:
+	// the calls changing them then panic with ErrFrozen, while lookups and
+	// injections keep working. The mappings registered with a time to live
+	// still expire and are removed. Children and clones start unfrozen.
+	Freeze()
+
+	// Scoped returns a view of the injector that prepends the prefix and a
+	// slash to the keys of the mappings made and looked up through it, so
+	// that "token" in Scoped("auth") stands for "auth/token". The empty key
+	// stands for "auth/". Only the keys passed to the methods of the view
+	// are prefixed, not those of 'inject' tags nor the ones of the
+	// arguments resolved by Invoke and providers.
+	Scoped(string) Injector
+
+	// Clone returns an independent copy of the injector, sharing its parent.
+	// Mappings later added to either of them are not visible to the other,
+	// and providers not called yet are called once per injector.
+	Clone() Injector
+
+	// CopyTo copies the mappings of the injector, not those of its parents,
+	// into the given Injector in registration order. Type and key pairs
+	// already mapped in the destination itself are skipped, so that its own
+	// mappings win. Providers not called yet are copied as new providers of
+	// the same function, called at most once per injector. Expired mappings
+	// are skipped, the other mappings expiring keep the time they had left,
+	// and qualified mappings keep their qualifier.
+	CopyTo(Injector)
+
+	// Snapshot captures the mappings of the injector and returns a function
+	// restoring them, leaving the parent untouched. Providers not called yet
+	// when the snapshot is taken are called anew after each restore.
+	Snapshot() func()
+}
+
+// Initializer is implemented by structs that need to be initialized once
+// their dependencies are injected. Init is called by Inject after every
+// field is set, and its error is returned by Inject.
+type Initializer interface {
+	Init() error
+}
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// Observer is called after lookups, see SetObserver.
+type Observer func(typ reflect.Type, key string, found bool, duration time.Duration)
+
+// Registration identifies a mapping of an Injector.
+type Registration struct {
+	Type reflect.Type
+	Key  string
 }
 
+// injector is safe for concurrent use, mu guards all of its fields. Lookups
+// only read-lock it.
 type injector struct {
-	values map[reflect.Type]map[string]reflect.Value
-	parent Injector
+	mu        sync.RWMutex
+	values    map[reflect.Type]map[string]reflect.Value
+	providers map[reflect.Type]map[string]*provider
+	order     []Registration
+	parent    Injector
+
+	// implementors indexes the registrations by the interface types they
+	// implement, for the interfaces requested so far.
+	implementors map[reflect.Type][]Registration
+
+	// expiries holds the time after which the values registered with a
+	// time to live expire.
+	expiries map[Registration]time.Time
+
+	// qualifiers holds the qualifiers of the values registered with one.
+	qualifiers map[Registration]string
+
+	policy            OverridePolicy
+	implementorPolicy ImplementorPolicy
+	defaultKey        string
+	numericFallback   bool
+	tagName           string
+	keyTag            string
+	pointerAdapt      bool
+	stringDecoding    bool
+	decoders          map[reflect.Type]Decoder
+	frozen            bool
+	observer          atomic.Pointer[Observer]
 }
 
 // InterfaceOf dereferences a pointer to an Interface type.
 // It panics if value is not an pointer to an interface.
 func InterfaceOf(value interface{}) reflect.Type {
+	t, err := InterfaceOfE(value)
+	if err != nil {
+		panic(err.Error())
+	}
+	return t
+}
+
+// InterfaceOfE dereferences a pointer to an Interface type like InterfaceOf.
+// It returns an error instead of panicking if value is not a pointer to an
+// interface.
+func InterfaceOfE(value interface{}) (reflect.Type, error) {
 	t := reflect.TypeOf(value)
 
-	for t.Kind() == reflect.Ptr {
+	for t != nil && t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
 
-	if t.Kind() != reflect.Interface {
-		panic("Called inject.InterfaceOf with a value that is not a pointer to an interface. (*MyInterface)(nil)")
+	if t == nil || t.Kind() != reflect.Interface {
+		return nil, errors.New("Called inject.InterfaceOf with a value that is not a pointer to an interface. (*MyInterface)(nil)")
 	}
 
-	return t
+	return t, nil
 }
 
 // New returns a new Injector.
 func New() Injector {
 	return &injector{
-		values: make(map[reflect.Type]map[string]reflect.Value),
+		values:    make(map[reflect.Type]map[string]reflect.Value),
+		providers: make(map[reflect.Type]map[string]*provider),
+	}
+}
+
+// Pair is a value along with the key to register it under.
+type Pair struct {
+	Value interface{}
+	Key   string
+}
+
+// NewWith returns a new Injector with the value of every pair registered
+// under its key, by its dynamic type.
+func NewWith(pairs ...Pair) Injector {
+	inj := New()
+	for _, p := range pairs {
+		inj.Register(p.Value, p.Key)
 	}
+	return inj
 }
 
 // Maps dependencies in the Type map to each field in the struct
-// that is tagged with 'inject'.
+// that is tagged with 'inject', using the tag value as the key.
 // Returns an error if the injection fails.
 func (inj *injector) Inject(val interface{}) error {
+	return inj.inject(val, &injection{})
+}
+
+// Maps dependencies like Inject, then walks into every struct and non-nil
+// pointer to struct field, tagged or not, injecting them as well.
+// Pointers already visited are skipped to guard against cycles.
+func (inj *injector) InjectDeep(val interface{}) error {
+	return inj.inject(val, &injection{deep: true})
+}
+
+// Maps dependencies like Inject, including unexported tagged fields which
+// are made settable using package unsafe. The value must be a pointer for
+// its unexported fields to be addressable.
+func (inj *injector) InjectUnexported(val interface{}) error {
+	return inj.inject(val, &injection{unexported: true})
+}
+
+func (inj *injector) InjectAll(vals ...interface{}) error {
+	return inj.injectAll(vals, nil)
+}
+
+// injectAll injects every value of vals, or every element of those being
+// slices or arrays, continuing the resolution base.
+func (inj *injector) injectAll(vals []interface{}, base *resolution) error {
+	var errs []error
+	for i, val := range vals {
+		v := reflect.ValueOf(val)
+		if v.Kind() == reflect.Ptr && !v.IsNil() && (v.Elem().Kind() == reflect.Slice || v.Elem().Kind() == reflect.Array) {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			if err := inj.inject(val, &injection{base: base}); err != nil {
+				errs = append(errs, fmt.Errorf("%w (argument %d)", err, i))
+			}
+			continue
+		}
+		for j := 0; j < v.Len(); j++ {
+			e := v.Index(j)
+			if e.Kind() == reflect.Struct && e.CanAddr() {
+				e = e.Addr()
+			}
+			if err := inj.inject(e.Interface(), &injection{base: base}); err != nil {
+				errs = append(errs, fmt.Errorf("%w (argument %d, element %d)", err, i, j))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Maps dependencies like Inject, resolving the exported untagged fields by
+// their lowercased name, then by the default key.
+func (inj *injector) InjectByName(val interface{}) error {
+	return inj.inject(val, &injection{byName: true})
+}
+
+func (inj *injector) InjectAllErrors(val interface{}) error {
+	return inj.injectCollecting(val, &injection{})
+}
+
+func (inj *injector) CanInject(val interface{}) error {
+	return inj.injectCollecting(val, &injection{dry: true})
+}
+
+// injectCollecting runs the injection in collecting its errors, and returns
+// them joined.
+func (inj *injector) injectCollecting(val interface{}, in *injection) error {
+	in.collect = true
+	if err := inj.inject(val, in); err != nil {
+		return err
+	}
+	return errors.Join(in.errs...)
+}
+
+// Injects dependencies into a copy of the struct val, or of the struct val
+// points to, and returns the copy. val itself is never modified.
+func (inj *injector) InjectValue(val interface{}) (interface{}, error) {
+	return inj.injectValue(val, nil)
+}
+
+func (inj *injector) injectValue(val interface{}, base *resolution) (interface{}, error) {
+	v := reflect.ValueOf(val)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Cannot inject into value of type %v", reflect.TypeOf(val))
+	}
+
+	c := reflect.New(v.Type())
+	c.Elem().Set(v)
+	if err := inj.inject(c.Interface(), &injection{base: base}); err != nil {
+		return nil, err
+	}
+	return c.Elem().Interface(), nil
+}
+
+func (inj *injector) inject(val interface{}, in *injection) error {
+	in.tagName, in.keyTag = inj.tagsOf()
 	v := reflect.ValueOf(val)
 
 	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return fmt.Errorf("inject: target must not be a nil pointer, got %v", v.Type())
+		}
+		if in.deep {
+			in.visit(v)
+		}
 		v = v.Elem()
 	}
 
 	if v.Kind() != reflect.Struct {
-		return nil // Should not panic here ?
+		return fmt.Errorf("inject: target must be a struct or pointer to struct, got %v", reflect.TypeOf(val))
 	}
 
+	return inj.injectStruct(v, in)
+}
+
+func (inj *injector) injectStruct(v reflect.Value, in *injection) error {
+	if err := inj.injectFields(v, in); err != nil {
+		return err
+	}
+	if err := inj.injectMethods(v, in); err != nil {
+		return err
+	}
+	if len(in.errs) > 0 || in.dry {
+		return nil
+	}
+	return initialize(v)
+}
+
+// injectFields injects the fields of the struct v, including the fields of
+// its embedded structs.
+func (inj *injector) injectFields(v reflect.Value, in *injection) error {
 	t := v.Type()
 
-	for i := 0; i < v.NumField(); i++ {
-		f := v.Field(i)
-		sf := t.Field(i)
-		if !f.CanSet() {
+	for _, fi := range fieldsOf(t, in.tagName) {
+		f := v.Field(fi.index)
+		if fi.embedded && !fi.tagged {
+			if err := inj.injectEmbedded(f, in); err != nil {
+				return err
+			}
 			continue
 		}
-		if k, found := sf.Tag.Lookup("inject"); found {
-			ft := f.Type()
-			v := inj.Get(ft, k)
-			if !v.IsValid() {
-				return fmt.Errorf("Value not found for type %v", ft)
+		if !f.CanSet() {
+			if !in.unexported || !f.CanAddr() {
+				continue
+			}
+			f = reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+		}
+		if fi.tagged {
+			if fi.tagErr != nil {
+				if err := in.fail(fmt.Errorf("inject: %s.%s: %w", typeName(t), fi.name, fi.tagErr)); err != nil {
+					return err
+				}
+				continue
+			}
+			if fi.tag.Key == "" && in.keyTag != "" {
+				fi.tag.Key = keyFromTag(t.Field(fi.index).Tag, in.keyTag)
+			}
+			v, err := inj.resolveField(t, fi, f.Type(), in.resolution())
+			if err = in.fail(err); err != nil {
+				return err
+			}
+			if v.IsValid() && !in.dry {
+				f.Set(v)
+			}
+		} else if in.byName {
+			v, err := inj.resolveByName(fi.name, f.Type(), in.resolution())
+			if err = in.fail(err); err != nil {
+				return err
+			}
+			if v.IsValid() && !in.dry {
+				f.Set(v)
+			}
+		}
+		if in.deep {
+			if err := inj.injectNested(f, in); err != nil {
+				return err
 			}
-			f.Set(v)
 		}
 	}
 
 	return nil
 }
 
-func (inj *injector) mapOf(typ reflect.Type) map[string]reflect.Value {
-	m := inj.values[typ]
-	if m == nil {
-		m = map[string]reflect.Value{}
-		inj.values[typ] = m
+// injectEmbedded injects the fields of the embedded struct or pointer to
+// struct f, allocating the pointer if it is nil and its struct has fields to
+// inject. The Init method of an embedded struct is not called, as it is
+// promoted to the embedding struct.
+func (inj *injector) injectEmbedded(f reflect.Value, in *injection) error {
+	switch f.Kind() {
+	case reflect.Interface:
+		// an embedded interface has no fields of its own, it is injected
+		// like any other field when tagged, and left alone otherwise
+		return nil
+	case reflect.Struct:
+		return inj.injectFields(f, in)
+	case reflect.Ptr:
+		if f.Type().Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		if !f.IsNil() {
+			if !in.visit(f) {
+				return nil
+			}
+			return inj.injectFields(f.Elem(), in)
+		}
+		// recursive types would allocate pointers endlessly
+		t := f.Type().Elem()
+		if !f.CanSet() || in.allocating[t] || !injectable(t, in.tagName, nil) {
+			return nil
+		}
+		if in.allocating == nil {
+			in.allocating = map[reflect.Type]bool{}
+		}
+		in.allocating[t] = true
+		defer delete(in.allocating, t)
+		p := reflect.New(t)
+		if !in.dry {
+			f.Set(p)
+		}
+		in.visit(p)
+		return inj.injectFields(p.Elem(), in)
 	}
-	return m
+	return nil
 }
 
-// Maps the concrete value of val to its dynamic type using reflect.TypeOf,
-// It returns the TypeMapper registered in.
-func (inj *injector) Register(val interface{}, key string) Injector {
-	inj.mapOf(reflect.TypeOf(val))[key] = reflect.ValueOf(val)
-	return inj
+// initialize calls the Init method of v once its fields are injected, if v
+// or its address implements Initializer.
+func initialize(v reflect.Value) error {
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+	if !v.CanInterface() {
+		return nil
+	}
+	if i, ok := v.Interface().(Initializer); ok {
+		return i.Init()
+	}
+	return nil
 }
 
-func (inj *injector) RegisterAs(val interface{}, key string, ifacePtr interface{}) Injector {
-	inj.mapOf(InterfaceOf(ifacePtr))[key] = reflect.ValueOf(val)
-	return inj
+// resolveField resolves the Value of the tagged field fi of type ft in the
+// struct type t. The returned Value is invalid if the field is optional and
+// no value was found. A nil r starts a new resolution for each lookup.
+func (inj *injector) resolveField(t reflect.Type, fi fieldInfo, ft reflect.Type, r *resolution) (reflect.Value, error) {
+	if fi.tag.Zero {
+		return reflect.Zero(ft), nil
+	}
+	if fi.tag.Key == allKeys {
+		var v reflect.Value
+		var err error
+		switch {
+		case ft.Kind() == reflect.Slice:
+			v, err = inj.collectSlice(ft, r)
+		case ft.Kind() == reflect.Array:
+			v, err = inj.collectArray(ft, r)
+		case ft.Kind() == reflect.Map && ft.Key().Kind() == reflect.String:
+			v, err = inj.collectMap(ft, r)
+		}
+		if err != nil {
+			return v, fmt.Errorf("inject: %s.%s (key=%q): %w", typeName(t), fi.name, fi.tag.Key, err)
+		}
+		if v.IsValid() {
+			return v, nil
+		}
+	}
+
+	v, err := inj.resolve(ft, fi.tag.Key, r)
+	if err != nil {
+		var ae *AmbiguousError
+		if errors.As(err, &ae) && ae.Type == ft && ae.Struct == nil {
+			ae.Struct, ae.Field = t, fi.name
+		}
+		return v, err
+	}
+	var conversion error
+	if !v.IsValid() {
+		v, err = inj.resolveConvertible(ft, fi.tag.Key, r)
+		var ce *ConversionError
+		if errors.As(err, &ce) {
+			conversion, err = err, nil
+		}
+		if err != nil {
+			return v, err
+		}
+	}
+	if !v.IsValid() {
+		if v, err = inj.resolveAdapted(ft, fi.tag.Key, r); err != nil {
+			return v, err
+		}
+	}
+	if !v.IsValid() {
+		if v, err = inj.resolveDecoded(ft, fi.tag.Key, r); err != nil {
+			return v, fmt.Errorf("inject: %s.%s (key=%q): %w", typeName(t), fi.name, fi.tag.Key, err)
+		}
+	}
+	if !v.IsValid() && fi.tag.HasDefault {
+		if v, err = parseDefault(fi.tag.Default, ft); err != nil {
+			return v, fmt.Errorf("inject: %s.%s (key=%q): invalid default value %q: %w", typeName(t), fi.name, fi.tag.Key, fi.tag.Default, err)
+		}
+	}
+	if !v.IsValid() && !fi.tag.Optional {
+		if conversion != nil {
+			return v, fmt.Errorf("inject: %s.%s (key=%q): %w", typeName(t), fi.name, fi.tag.Key, conversion)
+		}
+		return v, &NotFoundError{Type: ft, Key: fi.tag.Key, Struct: t, Field: fi.name}
+	}
+	return v, nil
 }
 
-// Maps the given reflect.Type to the given reflect.Value and returns
-// the Typemapper the mapping has been registered in.
-func (inj *injector) Set(typ reflect.Type, key string, val reflect.Value) Injector {
-	inj.mapOf(typ)[key] = val
-	return inj
+// resolveByName resolves the Value of the untagged field named name of type
+// ft, looking it up under its lowercased name then under the default key.
+func (inj *injector) resolveByName(name string, ft reflect.Type, r *resolution) (reflect.Value, error) {
+	v, err := inj.resolve(ft, strings.ToLower(name), r)
+	if err != nil || v.IsValid() {
+		return v, err
+	}
+	return inj.resolve(ft, "", r)
 }
 
-func (inj *injector) Get(t reflect.Type, key string) reflect.Value {
-	val := inj.mapOf(t)[key]
+// resolveAdapted resolves a T for a *T, or a *T for a T, adapting it to ft
+// if the pointer adaptation is enabled.
+func (inj *injector) resolveAdapted(ft reflect.Type, key string, r *resolution) (reflect.Value, error) {
+	inj.mu.RLock()
+	enabled := inj.pointerAdapt
+	inj.mu.RUnlock()
+	if !enabled {
+		return reflect.Value{}, nil
+	}
 
-	if val.IsValid() {
-		return val
+	if ft.Kind() == reflect.Ptr {
+		v, err := inj.resolve(ft.Elem(), key, r)
+		if err != nil || !v.IsValid() {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(ft.Elem())
+		p.Elem().Set(v)
+		return p, nil
+	}
+	v, err := inj.resolve(reflect.PointerTo(ft), key, r)
+	if err != nil || !v.IsValid() {
+		return reflect.Value{}, err
+	}
+	if v.IsNil() {
+		// dry resolutions stand for the values of providers with zeros
+		if r != nil && r.dry {
+			return reflect.Zero(ft), nil
+		}
+		return reflect.Value{}, nil
 	}
+	return v.Elem(), nil
+}
 
-	// no concrete types found, try to find implementors
-	// if t is an interface
-	if t.Kind() == reflect.Interface {
-		for k, v := range inj.values {
-			if k.Implements(t) {
-				val = v[key]
-				if val.IsValid() {
-					break
-				}
+// injectNested injects into f if it is a struct or a non-nil pointer to a
+// struct that has not been visited yet, and into the elements of f if it is
+// a slice, an array or a map. The values held by interfaces, embedded or
+// not, are never walked.
+func (inj *injector) injectNested(f reflect.Value, in *injection) error {
+	for f.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			return nil
+		}
+		if !in.visit(f) {
+			return nil
+		}
+		f = f.Elem()
+	}
+
+	switch f.Kind() {
+	case reflect.Struct:
+		return inj.injectStruct(f, in)
+	case reflect.Slice, reflect.Array:
+		if !nestable(f.Type().Elem()) {
+			return nil
+		}
+		for i := 0; i < f.Len(); i++ {
+			if err := inj.injectNested(f.Index(i), in); err != nil {
+				return err
 			}
 		}
+	case reflect.Map:
+		if !nestable(f.Type().Elem()) {
+			return nil
+		}
+		return inj.injectMapValues(f, in)
+	}
+	return nil
+}
+
+// nestable reports whether values of type t may hold structs to inject,
+// sparing deep injections from walking through slices of bytes and such.
+func nestable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return true
 	}
+	return false
+}
 
-	// Still no type found, try to look it up on the parent
-	if !val.IsValid() && inj.parent != nil {
-		val = inj.parent.Get(t, key)
+// injectMapValues injects into the values of the map f. Map values are not
+// addressable, so struct and array values are injected into a copy which
+// replaces them.
+func (inj *injector) injectMapValues(f reflect.Value, in *injection) error {
+	et := f.Type().Elem()
+	if et.Kind() != reflect.Struct && et.Kind() != reflect.Array {
+		for _, k := range f.MapKeys() {
+			if err := inj.injectNested(f.MapIndex(k), in); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	return val
+	// maps read through unexported fields cannot be modified
+	if !f.CanInterface() {
+		return nil
+	}
+	for _, k := range f.MapKeys() {
+		c := reflect.New(et).Elem()
+		c.Set(f.MapIndex(k))
+		if err := inj.injectNested(c, in); err != nil {
+			return err
+		}
+		if !in.dry {
+			f.SetMapIndex(k, c)
+		}
+	}
+	return nil
+}
 
+// Invoke attempts to call the interface{} provided as a function,
+// providing dependencies for function arguments based on Type.
+// Returns a slice of reflect.Value representing the returned values
+// of the function. Returns an error if the injection fails.
+func (inj *injector) Invoke(f interface{}) ([]reflect.Value, error) {
+	return inj.invoke(f, nil, nil)
 }
 
-func (inj *injector) SetParent(parent Injector) {
-	inj.parent = parent
+// invoke calls f with its arguments resolved by r, or given by override.
+func (inj *injector) invoke(f interface{}, r *resolution, override func(reflect.Type) (reflect.Value, bool)) ([]reflect.Value, error) {
+	fv := reflect.ValueOf(f)
+	if fv.Kind() != reflect.Func {
+		return nil, fmt.Errorf("Cannot invoke non-function value of type %v", reflect.TypeOf(f))
+	}
+
+	in, err := inj.arguments(fv.Type(), r, override)
+	if err != nil {
+		return nil, err
+	}
+	return call(fv, in), nil
+}
+
+// InvokeCtx calls f like Invoke, passing ctx to every argument of type
+// context.Context, instead of resolving them from the injector.
+func (inj *injector) InvokeCtx(ctx context.Context, f interface{}) ([]reflect.Value, error) {
+	return inj.invokeCtx(ctx, f, nil)
+}
+
+func (inj *injector) invokeCtx(ctx context.Context, f interface{}, r *resolution) ([]reflect.Value, error) {
+	cv := reflect.ValueOf(&ctx).Elem()
+	return inj.invoke(f, r, func(t reflect.Type) (reflect.Value, bool) {
+		return cv, t == contextType
+	})
+}
+
+func (inj *injector) InvokeWith(f interface{}, overrides ...interface{}) ([]reflect.Value, error) {
+	return inj.invokeWith(f, overrides, nil)
+}
+
+func (inj *injector) invokeWith(f interface{}, overrides []interface{}, r *resolution) ([]reflect.Value, error) {
+	fv := reflect.ValueOf(f)
+	if fv.Kind() != reflect.Func {
+		return nil, fmt.Errorf("Cannot invoke non-function value of type %v", reflect.TypeOf(f))
+	}
+
+	ft := fv.Type()
+	values := make([]reflect.Value, len(overrides))
+	for i, o := range overrides {
+		values[i] = reflect.ValueOf(o)
+		accepted := false
+		for j := 0; j < ft.NumIn() && !accepted; j++ {
+			accepted = values[i].IsValid() && values[i].Type().AssignableTo(ft.In(j))
+		}
+		if !accepted {
+			return nil, fmt.Errorf("Cannot invoke %v with an override of type %v, accepted by none of its arguments", ft, reflect.TypeOf(o))
+		}
+	}
+
+	return inj.invoke(f, r, func(t reflect.Type) (reflect.Value, bool) {
+		for _, v := range values {
+			if v.Type().AssignableTo(t) {
+				return v, true
+			}
+		}
+		return reflect.Value{}, false
+	})
+}
+
+func (inj *injector) InvokeE(f interface{}) (interface{}, error) {
+	return inj.invokeE(f, nil)
+}
+
+func (inj *injector) invokeE(f interface{}, r *resolution) (interface{}, error) {
+	ft := reflect.TypeOf(f)
+	if ft == nil || ft.Kind() != reflect.Func {
+		return nil, fmt.Errorf("Cannot invoke non-function value of type %v", ft)
+	}
+	if n := ft.NumOut(); n == 0 || n > 2 || ft.Out(n-1) != errorType {
+		return nil, fmt.Errorf("Cannot invoke function of type %v: it must return (T, error) or error", ft)
+	}
+
+	out, err := inj.invoke(f, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err, _ := out[len(out)-1].Interface().(error); err != nil {
+		return nil, err
+	}
+	if len(out) == 1 {
+		return nil, nil
+	}
+	return out[0].Interface(), nil
+}
+
+// arguments resolves the arguments of the function type t. Arguments for
+// which override returns true are not resolved from the injector.
+func (inj *injector) arguments(t reflect.Type, r *resolution, override func(reflect.Type) (reflect.Value, bool)) ([]reflect.Value, error) {
+	in := make([]reflect.Value, t.NumIn())
+	for i := 0; i < t.NumIn(); i++ {
+		argType := t.In(i)
+		if override != nil {
+			if val, ok := override(argType); ok {
+				in[i] = val
+				continue
+			}
+		}
+		val, err := inj.resolve(argType, "", r)
+		if err != nil {
+			return nil, err
+		}
+		if !val.IsValid() {
+			return nil, fmt.Errorf("%w (argument %d)", &NotFoundError{Type: argType}, i)
+		}
+		in[i] = val
+	}
+	return in, nil
+}
+
+// call calls the function fv with the arguments in, passing the last one
+// as the variadic slice if fv is variadic.
+func call(fv reflect.Value, in []reflect.Value) []reflect.Value {
+	if fv.Type().IsVariadic() {
+		return fv.CallSlice(in)
+	}
+	return fv.Call(in)
+}
+
+// mapOf returns the bucket of typ, creating it if needed. Lookups read
+// inj.values directly instead, so that they never create buckets.
+// The caller must hold inj.mu for writing.
+func (inj *injector) mapOf(typ reflect.Type) map[string]reflect.Value {
+	m := inj.values[typ]
+	if m == nil {
+		m = map[string]reflect.Value{}
+		inj.values[typ] = m
+	}
+	return m
+}
+
+// Maps the concrete value of val to its dynamic type using reflect.TypeOf,
+// It returns the TypeMapper registered in.
+func (inj *injector) Register(val interface{}, key string) Injector {
+	return inj.Set(reflect.TypeOf(val), key, reflect.ValueOf(val))
+}
+
+// Maps the concrete value of val to its dynamic type under every key,
+// reflecting val only once.
+func (inj *injector) RegisterKeys(val interface{}, keys ...string) Injector {
+	return inj.SetKeys(reflect.TypeOf(val), reflect.ValueOf(val), keys...)
+}
+
+// Maps val like Register if typ and key are not mapped locally yet. The check
+// and the registration are done atomically.
+func (inj *injector) RegisterDefault(val interface{}, key string) Injector {
+	typ, v := reflect.TypeOf(val), reflect.ValueOf(val)
+	key = inj.keyOf(key)
+	checkValue(typ, v)
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if !inj.hasLocal(typ, key) || inj.expired(typ, key) {
+		inj.setValue(typ, key, v)
+	}
+	return inj
+}
+
+func (inj *injector) RegisterType(typ reflect.Type, val interface{}, key string) Injector {
+	v := reflect.ValueOf(val)
+	if val == nil && typ != nil && nilable(typ) {
+		v = reflect.Zero(typ)
+	}
+	return inj.Set(typ, key, v)
+}
+
+// nilable reports whether nil is a valid value of type t.
+func nilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+func (inj *injector) RegisterFunc(fn interface{}, key string) error {
+	return inj.registerFunc(fn, key, nil)
+}
+
+func (inj *injector) registerFunc(fn interface{}, key string, r *resolution) error {
+	out, err := inj.invoke(fn, r, nil)
+	if err != nil {
+		return err
+	}
+	if n := len(out); n > 0 && reflect.TypeOf(fn).Out(n-1) == errorType {
+		if err, _ := out[n-1].Interface().(error); err != nil {
+			return err
+		}
+		out = out[:n-1]
+	}
+
+	for i, v := range out {
+		if v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return fmt.Errorf("Cannot register nil interface value returned by %v (result %d)", reflect.TypeOf(fn), i)
+			}
+			out[i] = v.Elem()
+		}
+	}
+	for _, v := range out {
+		inj.Set(v.Type(), key, v)
+	}
+	return nil
+}
+
+func (inj *injector) RegisterAs(val interface{}, key string, ifacePtr interface{}) Injector {
+	return inj.Set(InterfaceOf(ifacePtr), key, reflect.ValueOf(val))
+}
+
+func (inj *injector) RegisterNil(typ reflect.Type, key string) Injector {
+	if typ == nil {
+		panic("Called inject.RegisterNil with a nil type")
+	}
+	if !nilable(typ) {
+		panic(fmt.Sprintf("Called inject.RegisterNil with type %v that cannot be nil", typ))
+	}
+	return inj.Set(typ, key, reflect.Zero(typ))
+}
+
+// Maps the given reflect.Type to the given reflect.Value and returns
+// the Typemapper the mapping has been registered in.
+func (inj *injector) Set(typ reflect.Type, key string, val reflect.Value) Injector {
+	key = inj.keyOf(key)
+	checkValue(typ, val)
+	inj.checkOverride(typ, key)
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.setValue(typ, key, val)
+	return inj
+}
+
+// Maps the given reflect.Type to the given reflect.Value under every key.
+func (inj *injector) SetKeys(typ reflect.Type, val reflect.Value, keys ...string) Injector {
+	checkValue(typ, val)
+	keys = append([]string(nil), keys...)
+	for i, key := range keys {
+		keys[i] = inj.keyOf(key)
+		inj.checkOverride(typ, keys[i])
+	}
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	for _, key := range keys {
+		inj.setValue(typ, key, val)
+	}
+	return inj
+}
+
+func (inj *injector) SetMap(m map[reflect.Type]reflect.Value, key string, skipInvalid bool) Injector {
+	types := make([]reflect.Type, 0, len(m))
+	for typ, val := range m {
+		if skipInvalid && !val.IsValid() {
+			continue
+		}
+		checkValue(typ, val)
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].String() < types[j].String()
+	})
+
+	key = inj.keyOf(key)
+	for _, typ := range types {
+		inj.checkOverride(typ, key)
+	}
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	for _, typ := range types {
+		inj.setValue(typ, key, m[typ])
+	}
+	return inj
+}
+
+func (inj *injector) SetBoth(typ reflect.Type, key string, val reflect.Value) Injector {
+	key = inj.keyOf(key)
+	checkValue(typ, val)
+	if val.Kind() == reflect.Interface && !val.IsNil() {
+		val = val.Elem()
+	}
+	both := typ.Kind() == reflect.Interface && val.Type() != typ
+	inj.checkOverride(typ, key)
+	if both {
+		inj.checkOverride(val.Type(), key)
+	}
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.setValue(typ, key, val)
+	if both {
+		inj.setValue(val.Type(), key, val)
+	}
+	return inj
+}
+
+// checkValue panics if val cannot be mapped to typ.
+func checkValue(typ reflect.Type, val reflect.Value) {
+	if typ == nil {
+		panic("Called inject.Set with a nil reflect.Type")
+	}
+	if !val.IsValid() {
+		panic(fmt.Sprintf("Called inject.Set with an invalid reflect.Value for type %v", typ))
+	}
+	if !val.Type().AssignableTo(typ) {
+		panic(fmt.Sprintf("Called inject.Set with a reflect.Value of type %v not assignable to type %v", val.Type(), typ))
+	}
+}
+
+// setValue maps typ and key to val, replacing any previous mapping.
+// The caller must hold inj.mu.
+func (inj *injector) setValue(typ reflect.Type, key string, val reflect.Value) {
+	inj.checkFrozen()
+	delete(inj.expiries, Registration{Type: typ, Key: key})
+	delete(inj.qualifiers, Registration{Type: typ, Key: key})
+	inj.track(typ, key)
+	inj.removeProvider(typ, key)
+	inj.mapOf(typ)[key] = val
+}
+
+// GetOrRegister returns the Value mapped to typ and key, calling create
+// and mapping its result if there is none. The check and the registration
+// are done atomically, so that concurrent callers get the same Value.
+// create must not use the injector.
+func (inj *injector) GetOrRegister(typ reflect.Type, key string, create func() reflect.Value) reflect.Value {
+	key = inj.keyOf(key)
+	if val := inj.Get(typ, key); val.IsValid() {
+		return val
+	}
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if val := inj.values[typ][key]; val.IsValid() {
+		return val
+	}
+	val := create()
+	checkValue(typ, val)
+	inj.setValue(typ, key, val)
+	return val
+}
+
+// track records a new registration of the given type and key.
+// The caller must hold inj.mu.
+func (inj *injector) track(typ reflect.Type, key string) {
+	if inj.hasLocal(typ, key) {
+		return
+	}
+	reg := Registration{Type: typ, Key: key}
+	inj.order = append(inj.order, reg)
+	for iface, regs := range inj.implementors {
+		if typ.Implements(iface) {
+			inj.implementors[iface] = append(regs, reg)
+		}
+	}
+}
+
+// Removes the mapping of the given reflect.Type and key, the type bucket
+// is dropped once it becomes empty.
+func (inj *injector) Unregister(typ reflect.Type, key string) bool {
+	key = inj.keyOf(key)
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	if !inj.hasLocal(typ, key) {
+		return false
+	}
+	inj.checkFrozen()
+	inj.unregister(typ, key)
+	return true
+}
+
+func (inj *injector) RemoveType(typ reflect.Type) int {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	var keys []string
+	for _, reg := range inj.order {
+		if reg.Type == typ {
+			keys = append(keys, reg.Key)
+		}
+	}
+	if len(keys) == 0 {
+		return 0
+	}
+	inj.checkFrozen()
+	for _, key := range keys {
+		inj.unregister(typ, key)
+	}
+	return len(keys)
+}
+
+// unregister removes the mapping of typ and key.
+// The caller must hold inj.mu.
+func (inj *injector) unregister(typ reflect.Type, key string) {
+	inj.removeValue(typ, key)
+	inj.removeProvider(typ, key)
+	reg := Registration{Type: typ, Key: key}
+	inj.order = without(inj.order, reg)
+	for iface, regs := range inj.implementors {
+		inj.implementors[iface] = without(regs, reg)
+	}
+}
+
+// without returns regs without reg, leaving regs untouched.
+func without(regs []Registration, reg Registration) []Registration {
+	for i, r := range regs {
+		if r == reg {
+			return append(regs[:i:i], regs[i+1:]...)
+		}
+	}
+	return regs
+}
+
+// implementorsOf returns the registrations whose type implements the
+// interface type iface, in registration order. Interface types implement
+// iface when their method set is a superset of the one of iface, so a value
+// mapped to an interface also serves the narrower interfaces.
+func (inj *injector) implementorsOf(iface reflect.Type) []Registration {
+	inj.mu.RLock()
+	regs, found := inj.implementors[iface]
+	inj.mu.RUnlock()
+	if found {
+		return regs
+	}
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	// another lookup may have indexed iface meanwhile
+	if regs, found = inj.implementors[iface]; found {
+		return regs
+	}
+	for _, r := range inj.order {
+		if r.Type.Implements(iface) {
+			regs = append(regs, r)
+		}
+	}
+	if inj.implementors == nil {
+		inj.implementors = map[reflect.Type][]Registration{}
+	}
+	inj.implementors[iface] = regs
+	return regs
+}
+
+// distinctImplementors returns candidates without the types mapped under key
+// to the same value as a previous one, such as the interface and concrete
+// types a value is mapped to by SetBoth. Types mapped to providers are kept.
+func (inj *injector) distinctImplementors(candidates []reflect.Type, key string) []reflect.Type {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	var distinct []reflect.Type
+	var seen []interface{}
+	for _, t := range candidates {
+		v, found := inj.values[t][key]
+		if !found || !v.CanInterface() {
+			distinct = append(distinct, t)
+			continue
+		}
+		// the dynamic values held by interfaces may not be comparable
+		i := v.Interface()
+		if i != nil && !reflect.ValueOf(i).Comparable() {
+			distinct = append(distinct, t)
+			continue
+		}
+		duplicate := false
+		for _, s := range seen {
+			if s == i {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			seen = append(seen, i)
+			distinct = append(distinct, t)
+		}
+	}
+	return distinct
+}
+
+func (inj *injector) Clear() {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.checkFrozen()
+
+	inj.values = make(map[reflect.Type]map[string]reflect.Value)
+	inj.providers = make(map[reflect.Type]map[string]*provider)
+	inj.order = nil
+	inj.implementors = nil
+	inj.expiries = nil
+	inj.qualifiers = nil
+}
+
+// Returns the registrations of the injector in the order they were added.
+func (inj *injector) Registrations() []Registration {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	return append([]Registration(nil), inj.order...)
+}
+
+func (inj *injector) Len() int {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	return len(inj.order)
+}
+
+func (inj *injector) IsEmpty() bool {
+	return inj.Len() == 0
+}
+
+func (inj *injector) TotalLen() int {
+	n := 0
+	visited := map[*injector]bool{}
+	var cur Injector = inj
+	for cur != nil {
+		i, ok := cur.(*injector)
+		if !ok {
+			return n + cur.TotalLen()
+		}
+		if visited[i] {
+			break
+		}
+		visited[i] = true
+		n += i.Len()
+		cur = i.Parent()
+	}
+	return n
+}
+
+func (inj *injector) Keys(typ reflect.Type) []string {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	keys := []string{}
+	for k := range inj.values[typ] {
+		keys = append(keys, k)
+	}
+	for k := range inj.providers[typ] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (inj *injector) ClearAll() {
+	inj.Clear()
+	inj.SetParent(nil)
+}
+
+func (inj *injector) Get(t reflect.Type, key string) reflect.Value {
+	val, _ := inj.get(t, key, nil)
+	return val
+}
+
+func (inj *injector) GetE(t reflect.Type, key string) (reflect.Value, error) {
+	val, err := inj.get(t, key, nil)
+	if err == nil && !val.IsValid() {
+		err = &NotFoundError{Type: t, Key: inj.keyOf(key)}
+	}
+	return val, err
+}
+
+func (inj *injector) MustGet(t reflect.Type, key string) reflect.Value {
+	val := inj.Get(t, key)
+	if !val.IsValid() {
+		panic(fmt.Sprintf("Value not found for type %v with key %q", t, key))
+	}
+	return val
+}
+
+func (inj *injector) GetAndInject(t reflect.Type, key string) (reflect.Value, error) {
+	return inj.getAndInject(t, key, nil)
+}
+
+// getAndInject gets the Value like GetE and injects it like Inject, both
+// continuing the resolution base.
+func (inj *injector) getAndInject(t reflect.Type, key string, base *resolution) (reflect.Value, error) {
+	val, err := inj.get(t, key, base.fork())
+	if err == nil && !val.IsValid() {
+		err = &NotFoundError{Type: t, Key: inj.keyOf(key)}
+	}
+	if err != nil {
+		return val, err
+	}
+
+	target := val
+	if target.Kind() == reflect.Interface {
+		target = target.Elem()
+	}
+	switch {
+	case target.Kind() == reflect.Struct:
+		copied := reflect.New(target.Type())
+		copied.Elem().Set(target)
+		if err := inj.inject(copied.Interface(), &injection{base: base}); err != nil {
+			return reflect.Value{}, err
+		}
+		if val.Kind() == reflect.Interface {
+			result := reflect.New(val.Type()).Elem()
+			result.Set(copied.Elem())
+			return result, nil
+		}
+		return copied.Elem(), nil
+	case target.Kind() == reflect.Ptr && target.Type().Elem().Kind() == reflect.Struct && !target.IsNil():
+		if err := inj.inject(target.Interface(), &injection{base: base}); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return val, nil
+}
+
+func (inj *injector) Has(t reflect.Type, key string) bool {
+	key = inj.keyOf(key)
+	if inj.HasLocal(t, key) {
+		return true
+	}
+	parent := inj.Parent()
+	return parent != nil && parent.Has(t, key)
+}
+
+func (inj *injector) HasLocal(t reflect.Type, key string) bool {
+	key = inj.keyOf(key)
+
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+	return inj.hasLocal(t, key) && !inj.expired(t, key)
+}
+
+func (inj *injector) Locate(t reflect.Type, key string) (Injector, bool) {
+	key = inj.keyOf(key)
+	if inj.HasLocal(t, key) {
+		return inj, true
+	}
+	parent := inj.Parent()
+	if parent == nil {
+		return nil, false
+	}
+	return parent.Locate(t, key)
+}
+
+// hasLocal is HasLocal for callers holding inj.mu.
+func (inj *injector) hasLocal(t reflect.Type, key string) bool {
+	if _, found := inj.values[t][key]; found {
+		return true
+	}
+	_, found := inj.providers[t][key]
+	return found
+}
+
+// resolve looks up the Value mapped to the given type and key, tracking the
+// dependencies currently being resolved in r to detect circular dependencies.
+// A nil r starts a new resolution.
+func (inj *injector) resolve(t reflect.Type, key string, r *resolution) (reflect.Value, error) {
+	if r == nil {
+		r = resolutions.Get().(*resolution)
+		defer r.release()
+	}
+	if r.origin == nil {
+		r.origin = inj
+	}
+	key = inj.keyOf(key)
+	if err := r.enter(inj, t, key); err != nil {
+		return reflect.Value{}, err
+	}
+	defer r.leave()
+
+	val, err := inj.lookup(t, key, r)
+	if err != nil || val.IsValid() {
+		return val, err
+	}
+
+	// no concrete types found, try to find implementors
+	// if t is an interface, refusing to pick one if several match
+	if t.Kind() == reflect.Interface {
+		var candidates []reflect.Type
+		for _, r := range inj.implementorsOf(t) {
+			if r.Key == key {
+				candidates = append(candidates, r.Type)
+			}
+		}
+		if len(candidates) > 1 {
+			candidates = inj.distinctImplementors(candidates, key)
+		}
+		if len(candidates) > 1 {
+			picked, ok := inj.pickImplementor(candidates)
+			if !ok {
+				return reflect.Value{}, &AmbiguousError{Type: t, Key: key, Candidates: candidates}
+			}
+			candidates = []reflect.Type{picked}
+		}
+		if len(candidates) == 1 {
+			if val, err = inj.lookup(candidates[0], key, r); err != nil {
+				return val, err
+			}
+		}
+		if len(candidates) == 0 {
+			if val, err = inj.resolveAddressable(t, key, r); err != nil {
+				return val, err
+			}
+		}
+	}
+
+	// a qualified key selects the mapping qualified so under its key
+	if !val.IsValid() {
+		if val, err = inj.resolveQualified(t, key, r); err != nil {
+			return val, err
+		}
+	}
+
+	// a directional channel type can be served by a bidirectional channel
+	if !val.IsValid() && t.Kind() == reflect.Chan && t.ChanDir() != reflect.BothDir {
+		if val, err = inj.lookup(reflect.ChanOf(reflect.BothDir, t.Elem()), key, r); err != nil {
+			return val, err
+		}
+		if val.IsValid() {
+			val = val.Convert(t)
+		}
+	}
+
+	// Still no type found, try to look it up on the parent
+	if parent := inj.Parent(); !val.IsValid() && parent != nil {
+		if parent, ok := parent.(*injector); ok {
+			if val, err = parent.resolve(t, key, r); err != nil {
+				return val, err
+			}
+		} else {
+			val = parent.Get(t, key)
+		}
+	}
+
+	// Nothing mapped to the Injector type, the injector resolves itself
+	if !val.IsValid() && t == injectorType && inj == r.origin && key == inj.keyOf("") {
+		// the Injector itself is not part of the dependencies it resolves
+		self := inj.bind(r.stack[:len(r.stack)-1], r.dry)
+		val = reflect.ValueOf(&self).Elem()
+	}
+
+	return val, nil
+}
+
+// resolveAddressable looks for a non-pointer value mapped under key whose
+// pointer type implements the interface type t, as methods with pointer
+// receivers are not part of the method set of the value type. The address
+// of the value is returned if it is addressable, an error explaining the
+// mismatch otherwise.
+func (inj *injector) resolveAddressable(t reflect.Type, key string, r *resolution) (reflect.Value, error) {
+	for _, reg := range inj.Registrations() {
+		if reg.Key != key || reg.Type.Kind() == reflect.Ptr || reg.Type.Kind() == reflect.Interface ||
+			!reflect.PointerTo(reg.Type).Implements(t) {
+			continue
+		}
+		val, err := inj.lookup(reg.Type, key, r)
+		if err != nil || !val.IsValid() {
+			return val, err
+		}
+		if !val.CanAddr() {
+			return reflect.Value{}, &PointerReceiverError{Type: t, Key: key, Value: reg.Type}
+		}
+		return val.Addr(), nil
+	}
+	return reflect.Value{}, nil
+}
+
+func (inj *injector) Child() Injector {
+	inj.mu.RLock()
+	policy, defaultKey, numericFallback, tagName := inj.policy, inj.defaultKey, inj.numericFallback, inj.tagName
+	pointerAdapt, keyTag, implementorPolicy := inj.pointerAdapt, inj.keyTag, inj.implementorPolicy
+	stringDecoding, decoders := inj.stringDecoding, copyDecoders(inj.decoders)
+	inj.mu.RUnlock()
+
+	child := New()
+	child.SetParent(inj)
+	child.SetOverridePolicy(policy)
+	child.SetImplementorPolicy(implementorPolicy)
+	child.SetDefaultKey(defaultKey)
+	child.SetNumericFallback(numericFallback)
+	child.SetTagName(tagName)
+	child.SetKeyFromTag(keyTag)
+	child.SetPointerAdapt(pointerAdapt)
+	child.SetStringDecoding(stringDecoding)
+	for typ, decoder := range decoders {
+		child.RegisterDecoder(typ, decoder)
+	}
+	if observer := inj.observer.Load(); observer != nil {
+		child.SetObserver(*observer)
+	}
+	return child
+}
+
+func (inj *injector) Clone() Injector {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	clone := &injector{
+		parent:            inj.parent,
+		policy:            inj.policy,
+		implementorPolicy: inj.implementorPolicy,
+		defaultKey:        inj.defaultKey,
+		numericFallback:   inj.numericFallback,
+		tagName:           inj.tagName,
+		keyTag:            inj.keyTag,
+		pointerAdapt:      inj.pointerAdapt,
+		stringDecoding:    inj.stringDecoding,
+		decoders:          copyDecoders(inj.decoders),
+	}
+	clone.observer.Store(inj.observer.Load())
+	clone.copyFrom(inj)
+	return clone
+}
+
+// Snapshot captures the mappings of the injector, restoring them when the
+// returned function is called. The function can be called several times.
+func (inj *injector) Snapshot() func() {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	saved := &injector{}
+	saved.copyFrom(inj)
+	return func() {
+		inj.mu.Lock()
+		defer inj.mu.Unlock()
+		inj.checkFrozen()
+		inj.copyFrom(saved)
+	}
+}
+
+func (inj *injector) CopyTo(dst Injector) {
+	type mapping struct {
+		Registration
+		val reflect.Value
+		p   *provider
+		// ttl is the time left before the mapping expires, zero if it
+		// does not.
+		ttl time.Duration
+		// qualifier qualifies the mapping if qualified is true.
+		qualifier string
+		qualified bool
+	}
+
+	inj.mu.RLock()
+	now := time.Now()
+	mappings := make([]mapping, 0, len(inj.order))
+	for _, reg := range inj.order {
+		m := mapping{Registration: reg, val: inj.values[reg.Type][reg.Key], p: inj.providers[reg.Type][reg.Key]}
+		if at, found := inj.expiries[reg]; found {
+			if m.ttl = at.Sub(now); m.ttl <= 0 {
+				continue
+			}
+		}
+		m.qualifier, m.qualified = inj.qualifiers[reg]
+		mappings = append(mappings, m)
+	}
+	inj.mu.RUnlock()
+
+	for _, m := range mappings {
+		if dst.HasLocal(m.Type, m.Key) {
+			continue
+		}
+		switch {
+		case m.p == nil && m.ttl > 0:
+			dst.RegisterTTL(m.val.Interface(), m.Key, m.ttl)
+		case m.p == nil && m.qualified:
+			dst.RegisterQualified(m.val.Interface(), m.Key, m.qualifier)
+		case m.p == nil:
+			dst.Set(m.Type, m.Key, m.val)
+		case m.p.constructor && m.p.transient:
+			dst.ProvideTransient(m.p.fn.Interface(), m.Key)
+		case m.p.constructor:
+			dst.ProvideConstructor(m.p.fn.Interface(), m.Key)
+		case m.p.transient:
+			dst.Provider(m.p.fn.Interface(), m.Key)
+		default:
+			dst.Factory(m.p.fn.Interface(), m.Key)
+		}
+	}
+}
+
+// copyFrom replaces the mappings of inj with a copy of the ones of src.
+// Providers are copied uncalled, so that the values they create in either
+// injector are not shared with the other. The caller must hold inj.mu and
+// src.mu, or own them.
+func (inj *injector) copyFrom(src *injector) {
+	inj.values = make(map[reflect.Type]map[string]reflect.Value, len(src.values))
+	inj.providers = make(map[reflect.Type]map[string]*provider, len(src.providers))
+	inj.order = append([]Registration(nil), src.order...)
+	inj.implementors = nil
+	inj.expiries = nil
+	for reg, at := range src.expiries {
+		if inj.expiries == nil {
+			inj.expiries = make(map[Registration]time.Time, len(src.expiries))
+		}
+		inj.expiries[reg] = at
+	}
+	inj.qualifiers = nil
+	for reg, q := range src.qualifiers {
+		if inj.qualifiers == nil {
+			inj.qualifiers = make(map[Registration]string, len(src.qualifiers))
+		}
+		inj.qualifiers[reg] = q
+	}
+	for t, m := range src.values {
+		c := make(map[string]reflect.Value, len(m))
+		for k, v := range m {
+			c[k] = v
+		}
+		inj.values[t] = c
+	}
+	for t, m := range src.providers {
+		c := make(map[string]*provider, len(m))
+		for k, p := range m {
+			c[k] = &provider{fn: p.fn, constructor: p.constructor, transient: p.transient}
+		}
+		inj.providers[t] = c
+	}
+}
+
+// lookup returns the Value mapped to exactly the given type and key in the
+// injector itself, calling its provider if the Value was not created yet.
+func (inj *injector) lookup(t reflect.Type, key string, r *resolution) (reflect.Value, error) {
+	inj.mu.RLock()
+	expired := inj.expired(t, key)
+	val := inj.values[t][key]
+	p := inj.providers[t][key]
+	inj.mu.RUnlock()
+
+	if expired {
+		inj.mu.Lock()
+		if inj.expired(t, key) {
+			inj.unregister(t, key)
+		}
+		val, p = inj.values[t][key], inj.providers[t][key]
+		inj.mu.Unlock()
+	}
+
+	if val.IsValid() {
+		return val, nil
+	}
+	if p != nil {
+		return inj.provide(t, key, p, r)
+	}
+	return reflect.Value{}, nil
+}
+
+func (inj *injector) SetParent(parent Injector) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.checkFrozen()
+
+	inj.parent = parent
+}
+
+func (inj *injector) SetObserver(observer Observer) {
+	if observer == nil {
+		inj.observer.Store(nil)
+		return
+	}
+	inj.observer.Store(&observer)
+}
+
+func (inj *injector) SetPointerAdapt(enabled bool) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.pointerAdapt = enabled
+}
+
+// Sets the key used in place of the empty key.
+func (inj *injector) SetDefaultKey(key string) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	inj.defaultKey = key
+}
+
+// keyOf returns the default key of the injector if key is empty, key
+// otherwise.
+func (inj *injector) keyOf(key string) string {
+	if key != "" {
+		return key
+	}
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+	return inj.defaultKey
+}
+
+// Returns the parent of the injector.
+func (inj *injector) Parent() Injector {
+	inj.mu.RLock()
+	defer inj.mu.RUnlock()
+
+	return inj.parent
+}
+
+// injection holds the state of a single Inject call.
+type injection struct {
+	deep       bool
+	unexported bool
+	byName     bool
+	// tagName is the name of the struct tag read, keyTag the name of the tag
+	// empty keys are derived from.
+	tagName string
+	keyTag  string
+	visited map[visit]bool
+	// allocating holds the types of the nil embedded pointers being
+	// allocated.
+	allocating map[reflect.Type]bool
+	// collect makes field and method errors accumulate in errs instead of
+	// stopping the injection.
+	collect bool
+	errs    []error
+	// dry injections resolve the fields and the method arguments without
+	// setting nor calling anything.
+	dry bool
+	// base is the resolution the lookups continue, nil if they start anew.
+	base *resolution
+}
+
+// resolution returns the resolution the lookups of a field or method start,
+// continuing the base one, and dry if the injection is dry.
+func (in *injection) resolution() *resolution {
+	r := in.base.fork()
+	if in.dry {
+		if r == nil {
+			r = &resolution{}
+		}
+		r.dry = true
+	}
+	return r
+}
+
+// fail records err if errors are collected, returning nil so that the
+// injection goes on, and returns err otherwise.
+func (in *injection) fail(err error) error {
+	if !in.collect || err == nil {
+		return err
+	}
+	in.errs = append(in.errs, err)
+	return nil
+}
+
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// visit marks the pointer p as visited, reporting whether it was not yet.
+func (in *injection) visit(p reflect.Value) bool {
+	if in.visited == nil {
+		in.visited = map[visit]bool{}
+	}
+	key := visit{p.Pointer(), p.Type()}
+	if in.visited[key] {
+		return false
+	}
+	in.visited[key] = true
+	return true
+}
+
+// resolution keeps track of the dependencies being resolved.
+// A dry resolution checks that providers could be called, without calling
+// them, and resolves their dependencies to zero Values.
+type resolution struct {
+	stack []dependency
+	dry   bool
+	// origin is the injector the resolution started from.
+	origin *injector
+}
+
+type dependency struct {
+	inj *injector
+	typ reflect.Type
+	key string
+}
+
+// resolutions recycles the resolutions started by resolve, whose stack
+// would otherwise be allocated by every lookup.
+var resolutions = sync.Pool{New: func() interface{} { return &resolution{} }}
+
+// release resets r, dropping the injectors its stack refers to, and puts it
+// back into resolutions.
+func (r *resolution) release() {
+	stack := r.stack[:cap(r.stack)]
+	for i := range stack {
+		stack[i] = dependency{}
+	}
+	*r = resolution{stack: stack[:0]}
+	resolutions.Put(r)
+}
+
+// fork returns a copy of r which can be entered and left independently, or
+// nil if r is nil.
+func (r *resolution) fork() *resolution {
+	if r == nil {
+		return nil
+	}
+	return &resolution{stack: append([]dependency(nil), r.stack...), dry: r.dry, origin: r.origin}
+}
+
+// enter pushes the dependency onto the stack, returning an error if it is
+// already being resolved.
+func (r *resolution) enter(inj *injector, typ reflect.Type, key string) error {
+	d := dependency{inj: inj, typ: typ, key: key}
+	for i, e := range r.stack {
+		if e == d {
+			chain := make([]reflect.Type, 0, len(r.stack)-i+1)
+			for _, e := range r.stack[i:] {
+				chain = append(chain, e.typ)
+			}
+			return &CycleError{Chain: append(chain, typ)}
+		}
+	}
+	r.stack = append(r.stack, d)
+	return nil
+}
+
+// leave pops the last dependency from the stack.
+func (r *resolution) leave() {
+	r.stack = r.stack[:len(r.stack)-1]
 }
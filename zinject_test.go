@@ -1,10 +1,17 @@
 package zinject_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/zionkit/zinject"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type SpecialString interface {
@@ -16,6 +23,12 @@ type TestStruct struct {
 	Dep3 string
 }
 
+type NamedStruct struct {
+	Primary string `inject:"primary"`
+	Replica string `inject:"replica"`
+	Default string `inject:""`
+}
+
 type Greeter struct {
 	Name string
 }
@@ -51,6 +64,33 @@ func Test_InjectorApply(t *testing.T) {
 	expect(t, s.Dep3, "")
 }
 
+func Test_InjectorApplyNamed(t *testing.T) {
+	injector := zinject.New()
+
+	injector.Register("primary dep", "primary").
+		Register("replica dep", "replica").
+		Register("default dep", "")
+
+	s := NamedStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+
+	expect(t, s.Primary, "primary dep")
+	expect(t, s.Replica, "replica dep")
+	expect(t, s.Default, "default dep")
+}
+
+func Test_InjectorApplyNamedMissing(t *testing.T) {
+	injector := zinject.New()
+
+	injector.Register("primary dep", "primary").
+		Register("default dep", "")
+
+	s := NamedStruct{}
+	err := injector.Inject(&s)
+	refute(t, err, nil)
+}
+
 func Test_InterfaceOf(t *testing.T) {
 	iType := zinject.InterfaceOf((*SpecialString)(nil))
 	expect(t, iType.Kind(), reflect.Interface)
@@ -85,6 +125,31 @@ func Test_InjectorSet(t *testing.T) {
 	expect(t, injector.Get(chanSend.Type(), "").IsValid(), false)
 }
 
+type ChanStruct struct {
+	Send chan<- string `inject:""`
+	Recv <-chan string `inject:""`
+}
+
+func Test_InjectorChanDirection(t *testing.T) {
+	injector := zinject.New()
+	ch := make(chan string, 1)
+	injector.Register(ch, "")
+
+	send := injector.Get(reflect.TypeOf((chan<- string)(nil)), "")
+	expect(t, send.IsValid(), true)
+	expect(t, send.Type(), reflect.TypeOf((chan<- string)(nil)))
+
+	s := ChanStruct{}
+	err := injector.Child().Inject(&s)
+	expect(t, err, nil)
+	s.Send <- "message"
+	expect(t, <-s.Recv, "message")
+
+	_, err = injector.Invoke(func(recv <-chan string) {})
+	expect(t, err, nil)
+	expect(t, injector.Get(reflect.TypeOf((chan<- int)(nil)), "").IsValid(), false)
+}
+
 func Test_InjectorGet(t *testing.T) {
 	injector := zinject.New()
 
@@ -104,6 +169,24 @@ func Test_InjectorSetParent(t *testing.T) {
 	expect(t, injector2.Get(zinject.InterfaceOf((*SpecialString)(nil)), "").IsValid(), true)
 }
 
+func Test_InjectorParent(t *testing.T) {
+	injector := zinject.New()
+	expect(t, injector.Parent(), nil)
+
+	child := injector.Child()
+	expect(t, child.Parent(), injector)
+
+	// push a scope and pop back to its parent
+	scope := child.Child()
+	scope.Register("scoped dep", "")
+	scope = scope.Parent()
+	expect(t, scope, child)
+	expect(t, scope.Has(reflect.TypeOf("string"), ""), false)
+
+	child.SetParent(nil)
+	expect(t, child.Parent(), nil)
+}
+
 func TestInjectImplementors(t *testing.T) {
 	injector := zinject.New()
 	g := &Greeter{"Jeremy"}
@@ -111,3 +194,1726 @@ func TestInjectImplementors(t *testing.T) {
 
 	expect(t, injector.Get(zinject.InterfaceOf((*fmt.Stringer)(nil)), "").IsValid(), true)
 }
+
+func Test_InjectorInvoke(t *testing.T) {
+	injector := zinject.New()
+	dep := "some dependency"
+	injector.Register(dep, "")
+	dep2 := "another dep"
+	injector.RegisterAs(dep2, "", (*SpecialString)(nil))
+
+	result, err := injector.Invoke(func(d1 string, d2 SpecialString) string {
+		expect(t, d1, dep)
+		expect(t, d2, dep2)
+		return "Hello world"
+	})
+
+	expect(t, err, nil)
+	expect(t, len(result), 1)
+	expect(t, result[0].String(), "Hello world")
+}
+
+func Test_InjectorInvokeMissing(t *testing.T) {
+	injector := zinject.New()
+
+	_, err := injector.Invoke(func(d1 string, d2 int) {})
+	refute(t, err, nil)
+
+	injector.Register("a dep", "")
+	_, err = injector.Invoke(func(d1 string, d2 int) {})
+	refute(t, err, nil)
+	expect(t, err.Error(), "Value not found for type int (argument 1)")
+}
+
+func Test_InjectorInvokeNonFunc(t *testing.T) {
+	injector := zinject.New()
+
+	_, err := injector.Invoke("not a function")
+	refute(t, err, nil)
+}
+
+func Test_InjectorUnregister(t *testing.T) {
+	injector := zinject.New()
+	typ := reflect.TypeOf("string")
+
+	injector.Register("a dep", "").Register("another dep", "other")
+
+	expect(t, injector.Unregister(typ, ""), true)
+	expect(t, injector.Get(typ, "").IsValid(), false)
+	expect(t, injector.Get(typ, "other").IsValid(), true)
+
+	expect(t, injector.Unregister(typ, ""), false)
+	expect(t, injector.Unregister(typ, "other"), true)
+	expect(t, injector.Unregister(reflect.TypeOf(11), ""), false)
+}
+
+func Test_InjectorRemoveType(t *testing.T) {
+	parent := zinject.New()
+	parent.Register("parent dep", "")
+
+	injector := parent.Child()
+	typ := reflect.TypeOf("string")
+	injector.Register("a dep", "").Register("another dep", "other").Register(11, "")
+	injector.Factory(func() string { return "lazy dep" }, "lazy")
+
+	expect(t, injector.RemoveType(typ), 3)
+	expect(t, injector.HasLocal(typ, "other"), false)
+	expect(t, injector.Has(typ, "lazy"), false)
+	expect(t, injector.Get(typ, "").String(), "parent dep")
+	expect(t, injector.Get(reflect.TypeOf(11), "").Int(), int64(11))
+	expect(t, injector.RemoveType(typ), 0)
+	expect(t, parent.Len(), 1)
+}
+
+func Test_InjectorClear(t *testing.T) {
+	parent := zinject.New()
+	parent.Register(11, "")
+
+	injector := zinject.New()
+	injector.SetParent(parent)
+	injector.Register("a dep", "")
+
+	injector.Clear()
+	expect(t, injector.Get(reflect.TypeOf("string"), "").IsValid(), false)
+	expect(t, injector.Get(reflect.TypeOf(11), "").IsValid(), true)
+
+	injector.Register("a dep", "")
+	injector.ClearAll()
+	expect(t, injector.Get(reflect.TypeOf("string"), "").IsValid(), false)
+	expect(t, injector.Get(reflect.TypeOf(11), "").IsValid(), false)
+}
+
+func Test_InjectorCircularParent(t *testing.T) {
+	injector := zinject.New()
+	injector2 := zinject.New()
+	injector.SetParent(injector2)
+	injector2.SetParent(injector)
+
+	expect(t, injector.Get(reflect.TypeOf("string"), "").IsValid(), false)
+
+	s := TestStruct{}
+	err := injector.Inject(&s)
+	refute(t, err, nil)
+	expect(t, err.Error(), "circular dependency detected: string -> string -> string")
+}
+
+type DeepLeaf struct {
+	Dep string `inject:""`
+}
+
+type DeepNode struct {
+	Leaf    DeepLeaf
+	LeafPtr *DeepLeaf
+	NilPtr  *DeepLeaf
+	Self    *DeepNode
+}
+
+func Test_InjectorInjectDeep(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+
+	s := DeepNode{LeafPtr: &DeepLeaf{}}
+	s.Self = &s
+
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Leaf.Dep, "")
+	expect(t, s.LeafPtr.Dep, "")
+
+	err = injector.InjectDeep(&s)
+	expect(t, err, nil)
+	expect(t, s.Leaf.Dep, "a dep")
+	expect(t, s.LeafPtr.Dep, "a dep")
+	expect(t, s.NilPtr == nil, true)
+
+	err = zinject.New().InjectDeep(&s)
+	refute(t, err, nil)
+}
+
+type DeepTree struct {
+	Slice    []DeepLeaf
+	Pointers []*DeepLeaf
+	Array    [2]DeepLeaf
+	Map      map[string]DeepLeaf
+	MapPtrs  map[string]*DeepLeaf
+	Nested   map[string][]DeepLeaf
+}
+
+func Test_InjectorInjectDeepElements(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+
+	s := DeepTree{
+		Slice:    []DeepLeaf{{}, {}},
+		Pointers: []*DeepLeaf{{}, nil},
+		Map:      map[string]DeepLeaf{"a": {}, "b": {}},
+		MapPtrs:  map[string]*DeepLeaf{"a": {}},
+		Nested:   map[string][]DeepLeaf{"a": {{}}},
+	}
+	err := injector.InjectDeep(&s)
+	expect(t, err, nil)
+	expect(t, s.Slice[1].Dep, "a dep")
+	expect(t, s.Pointers[0].Dep, "a dep")
+	expect(t, s.Array[1].Dep, "a dep")
+	expect(t, s.Map["a"].Dep, "a dep")
+	expect(t, s.Map["b"].Dep, "a dep")
+	expect(t, s.MapPtrs["a"].Dep, "a dep")
+	expect(t, s.Nested["a"][0].Dep, "a dep")
+
+	err = zinject.New().InjectDeep(&DeepTree{Map: map[string]DeepLeaf{"a": {}}})
+	refute(t, err, nil)
+}
+
+type OptionalStruct struct {
+	Required string `inject:""`
+	Optional int    `inject:",optional"`
+	Named    int    `inject:"named,optional"`
+}
+
+func Test_InjectorOptional(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "").Register(42, "named")
+
+	s := OptionalStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Required, "a dep")
+	expect(t, s.Optional, 0)
+	expect(t, s.Named, 42)
+
+	s = OptionalStruct{}
+	err = zinject.New().Inject(&s)
+	refute(t, err, nil)
+}
+
+func Test_InjectorMustGet(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("some dependency", "")
+
+	expect(t, injector.MustGet(reflect.TypeOf("string"), "").String(), "some dependency")
+
+	defer func() {
+		rec := recover()
+		expect(t, rec, `Value not found for type int with key "missing"`)
+	}()
+	injector.MustGet(reflect.TypeOf(11), "missing")
+}
+
+func Test_InjectorHas(t *testing.T) {
+	parent := zinject.New()
+	parent.Register(11, "")
+
+	injector := zinject.New()
+	injector.SetParent(parent)
+	injector.Register(&Greeter{"Jeremy"}, "")
+
+	expect(t, injector.Has(reflect.TypeOf(&Greeter{}), ""), true)
+	expect(t, injector.Has(reflect.TypeOf(11), ""), true)
+	expect(t, injector.Has(reflect.TypeOf(11), "other"), false)
+	expect(t, injector.Has(zinject.InterfaceOf((*fmt.Stringer)(nil)), ""), false)
+
+	expect(t, injector.HasLocal(reflect.TypeOf(&Greeter{}), ""), true)
+	expect(t, injector.HasLocal(reflect.TypeOf(11), ""), false)
+}
+
+type Farewell struct {
+	Name string
+}
+
+func (f *Farewell) String() string {
+	return "Goodbye, " + f.Name
+}
+
+type StringerStruct struct {
+	Dep fmt.Stringer `inject:""`
+}
+
+func TestInjectImplementorsAmbiguous(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(&Greeter{"Jeremy"}, "").Register(&Farewell{"Jeremy"}, "")
+
+	expect(t, injector.Get(zinject.InterfaceOf((*fmt.Stringer)(nil)), "").IsValid(), false)
+
+	s := StringerStruct{}
+	err := injector.Inject(&s)
+	refute(t, err, nil)
+	expect(t, err.Error(), `inject: StringerStruct.Dep (key=""): ambiguous dependency for type fmt.Stringer: implemented by *zinject_test.Greeter, *zinject_test.Farewell`)
+
+	// only one implementor is registered under the key
+	injector.Register(&Farewell{"Jeremy"}, "farewell")
+	expect(t, injector.Get(zinject.InterfaceOf((*fmt.Stringer)(nil)), "farewell").IsValid(), true)
+}
+
+type KeyedStringers struct {
+	Hello fmt.Stringer `inject:"hello"`
+	Bye   fmt.Stringer `inject:"bye"`
+}
+
+func TestInjectImplementorsKeyed(t *testing.T) {
+	parent := zinject.New()
+	parent.Factory(func() *Farewell { return &Farewell{"Jeremy"} }, "bye")
+
+	injector := parent.Child()
+	injector.Register(&Greeter{"Jeremy"}, "hello")
+
+	s := KeyedStringers{}
+	expect(t, injector.Inject(&s), nil)
+	expect(t, s.Hello.String(), "Hello, My name isJeremy")
+	expect(t, s.Bye.String(), "Goodbye, Jeremy")
+
+	// two implementors under the same key are ambiguous, whatever the others
+	injector.Register(&Farewell{"Jeremy"}, "hello")
+	s = KeyedStringers{}
+	refute(t, injector.Inject(&s), nil)
+	injector.Unregister(reflect.TypeOf(&Greeter{}), "hello")
+	expect(t, injector.Inject(&s), nil)
+	expect(t, s.Hello.String(), "Goodbye, Jeremy")
+}
+
+func Test_InjectorRegistrations(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "").
+		Register(11, "").
+		Register("another dep", "other").
+		Register("a dep again", "")
+
+	expect(t, fmt.Sprint(injector.Registrations()), "[{string } {int } {string other}]")
+
+	injector.Unregister(reflect.TypeOf(11), "")
+	expect(t, fmt.Sprint(injector.Registrations()), "[{string } {string other}]")
+
+	injector.Clear()
+	expect(t, len(injector.Registrations()), 0)
+}
+
+func BenchmarkInject(b *testing.B) {
+	injector := zinject.New()
+	injector.Register("a dep", "").RegisterAs("another dep", "", (*SpecialString)(nil))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := TestStruct{}
+		if err := injector.Inject(&s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Test_InjectorChild(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("parent dep", "").Register(11, "")
+
+	child := injector.Child()
+	child.Register("child dep", "")
+
+	expect(t, child.Get(reflect.TypeOf("string"), "").String(), "child dep")
+	expect(t, child.Get(reflect.TypeOf(11), "").Interface(), 11)
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "parent dep")
+	expect(t, len(injector.Registrations()), 2)
+}
+
+type DefaultStruct struct {
+	Host    string  `inject:"host,default=localhost"`
+	Port    int     `inject:"port,default=8080"`
+	Debug   bool    `inject:"debug,default=true"`
+	Ratio   float64 `inject:"ratio,default=0.5"`
+	Workers uint8   `inject:"workers,default=4"`
+}
+
+type InvalidDefaultStruct struct {
+	Port int `inject:"port,default=http"`
+}
+
+func Test_InjectorDefault(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(9090, "port")
+
+	s := DefaultStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Host, "localhost")
+	expect(t, s.Port, 9090)
+	expect(t, s.Debug, true)
+	expect(t, s.Ratio, 0.5)
+	expect(t, s.Workers, uint8(4))
+
+	// the default is only parsed when no value is registered
+	err = injector.Inject(&InvalidDefaultStruct{})
+	expect(t, err, nil)
+
+	err = zinject.New().Inject(&InvalidDefaultStruct{})
+	expect(t, err.Error(), `inject: InvalidDefaultStruct.Port (key="port"): invalid default value "http": strconv.ParseInt: parsing "http": invalid syntax`)
+	var ne *strconv.NumError
+	expect(t, errors.As(err, &ne), true)
+}
+
+type ZeroStruct struct {
+	Dep     string            `inject:""`
+	Session string            `inject:"session,zero"`
+	Cache   map[string]string `inject:",zero"`
+}
+
+func Test_InjectorZero(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "").Register("stale session", "session")
+
+	s := ZeroStruct{Session: "previous request", Cache: map[string]string{"a": "b"}}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Dep, "a dep")
+	expect(t, s.Session, "")
+	expect(t, s.Cache == nil, true)
+
+	// nothing needs to be mapped
+	err = zinject.New().Inject(&ZeroStruct{Dep: "set"})
+	refute(t, err, nil)
+	s = ZeroStruct{Session: "previous request"}
+	err = zinject.New().Register("", "").Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Session, "")
+}
+
+type UnexportedStruct struct {
+	dep      string `inject:""`
+	untagged string
+	Exported int `inject:""`
+}
+
+func Test_InjectorInjectUnexported(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "").Register(11, "")
+
+	s := UnexportedStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.dep, "")
+	expect(t, s.Exported, 11)
+
+	err = injector.InjectUnexported(&s)
+	expect(t, err, nil)
+	expect(t, s.dep, "a dep")
+	expect(t, s.untagged, "")
+}
+
+func Test_InjectorClone(t *testing.T) {
+	parent := zinject.New()
+	parent.Register(11, "")
+
+	injector := zinject.New()
+	injector.SetParent(parent)
+	injector.Register("a dep", "")
+
+	clone := injector.Clone()
+	clone.Register("another dep", "").Register("other dep", "other")
+	injector.Register(&Greeter{"Jeremy"}, "")
+
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "a dep")
+	expect(t, injector.Get(reflect.TypeOf("string"), "other").IsValid(), false)
+	expect(t, clone.Get(reflect.TypeOf("string"), "").String(), "another dep")
+	expect(t, clone.Get(reflect.TypeOf(&Greeter{}), "").IsValid(), false)
+	expect(t, clone.Get(reflect.TypeOf(11), "").IsValid(), true)
+	expect(t, len(injector.Registrations()), 2)
+	expect(t, len(clone.Registrations()), 2)
+}
+
+func Test_InjectorCloneProviders(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(&Database{DSN: "mysql://"}, "")
+	injector.ProvideConstructor(func(db *Database) *Repository { return &Repository{DB: db} }, "")
+
+	clone := injector.Clone()
+	clone.Register(&Database{DSN: "mock://"}, "")
+	repo, _ := zinject.Get[*Repository](clone, "")
+	expect(t, repo.DB.DSN, "mock://")
+
+	repo, _ = zinject.Get[*Repository](injector, "")
+	expect(t, repo.DB.DSN, "mysql://")
+}
+
+func Test_InjectorCopyTo(t *testing.T) {
+	parent := zinject.New()
+	parent.Register(11, "")
+
+	plugin := parent.Child()
+	plugin.Register("plugin dep", "").Register("plugin name", "name")
+	plugin.Factory(func() *Greeter { return &Greeter{"Jeremy"} }, "")
+
+	app := zinject.New()
+	app.Register("app dep", "")
+	plugin.CopyTo(app)
+
+	expect(t, app.Get(reflect.TypeOf("string"), "").String(), "app dep")
+	expect(t, app.Get(reflect.TypeOf("string"), "name").String(), "plugin name")
+	expect(t, app.Has(reflect.TypeOf(11), ""), false)
+	expect(t, app.Parent(), nil)
+	expect(t, len(app.Registrations()), 3)
+
+	// the factory is called once per injector
+	g := app.Get(reflect.TypeOf(&Greeter{}), "").Interface().(*Greeter)
+	expect(t, g.Name, "Jeremy")
+	expect(t, app.Get(reflect.TypeOf(&Greeter{}), "").Interface(), g)
+	refute(t, plugin.Get(reflect.TypeOf(&Greeter{}), "").Interface(), g)
+}
+
+func Test_InjectorKeys(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("replica dep", "replica").Register("primary dep", "primary")
+	injector.Factory(func() string { return "lazy dep" }, "lazy")
+
+	expect(t, fmt.Sprint(injector.Keys(reflect.TypeOf("string"))), "[lazy primary replica]")
+	expect(t, fmt.Sprint(injector.Child().Keys(reflect.TypeOf("string"))), "[]")
+
+	keys := injector.Keys(reflect.TypeOf(11))
+	expect(t, keys != nil, true)
+	expect(t, len(keys), 0)
+}
+
+type InitStruct struct {
+	Dep      string `inject:""`
+	Greeting string
+}
+
+func (s *InitStruct) Init() error {
+	if s.Dep == "" {
+		return errors.New("dep not injected")
+	}
+	s.Greeting = "Hello " + s.Dep
+	return nil
+}
+
+func Test_InjectorInitializer(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("Jeremy", "")
+
+	s := InitStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Greeting, "Hello Jeremy")
+
+	// a struct value is neither settable nor addressable
+	err = injector.Inject(InitStruct{})
+	expect(t, err, nil)
+
+	err = zinject.New().Inject(&InitStruct{Dep: ""})
+	refute(t, err, nil)
+}
+
+func Test_InjectorInitializerError(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("", "")
+
+	err := injector.Inject(&InitStruct{})
+	expect(t, err.Error(), "dep not injected")
+}
+
+type ctxKey struct{}
+
+func Test_InjectorInvokeCtx(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+	injector.Register(context.TODO(), "")
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	result, err := injector.InvokeCtx(ctx, func(c1 context.Context, d string, c2 context.Context) string {
+		expect(t, c1, ctx)
+		expect(t, c2, ctx)
+		return d + " " + c1.Value(ctxKey{}).(string)
+	})
+	expect(t, err, nil)
+	expect(t, result[0].String(), "a dep request")
+
+	_, err = injector.InvokeCtx(ctx, func(c context.Context, i int) {})
+	refute(t, err, nil)
+
+	_, err = injector.InvokeCtx(ctx, "not a function")
+	refute(t, err, nil)
+}
+
+func Test_InjectorSetInvalid(t *testing.T) {
+	injector := zinject.New()
+	typ := reflect.TypeOf("string")
+
+	for _, tc := range []struct {
+		typ reflect.Type
+		val reflect.Value
+		msg string
+	}{
+		{typ, reflect.Value{}, "Called inject.Set with an invalid reflect.Value for type string"},
+		{typ, reflect.ValueOf(11), "Called inject.Set with a reflect.Value of type int not assignable to type string"},
+		{nil, reflect.ValueOf(11), "Called inject.Set with a nil reflect.Type"},
+	} {
+		func() {
+			defer func() {
+				expect(t, recover(), tc.msg)
+			}()
+			injector.Set(tc.typ, "", tc.val)
+		}()
+	}
+
+	expect(t, len(injector.Registrations()), 0)
+
+	func() {
+		defer func() {
+			refute(t, recover(), nil)
+		}()
+		injector.RegisterAs("a dep", "", (*fmt.Stringer)(nil))
+	}()
+}
+
+func Test_InjectorGetOrRegister(t *testing.T) {
+	injector := zinject.New()
+	typ := reflect.TypeOf(&Greeter{})
+	injector.Register(&Greeter{"Existing"}, "existing")
+
+	v := injector.GetOrRegister(typ, "existing", func() reflect.Value {
+		t.Error("create should not be called for an existing value")
+		return reflect.Value{}
+	})
+	expect(t, v.Interface().(*Greeter).Name, "Existing")
+
+	var calls int32
+	var wg sync.WaitGroup
+	results := make([]interface{}, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = injector.GetOrRegister(typ, "", func() reflect.Value {
+				atomic.AddInt32(&calls, 1)
+				return reflect.ValueOf(&Greeter{"Jeremy"})
+			}).Interface()
+		}(i)
+	}
+	wg.Wait()
+
+	expect(t, calls, int32(1))
+	for _, r := range results {
+		expect(t, r, results[0])
+	}
+	expect(t, injector.Get(typ, "").Interface(), results[0])
+}
+
+func TestInjectImplementorsIndex(t *testing.T) {
+	injector := zinject.New()
+	stringer := zinject.InterfaceOf((*fmt.Stringer)(nil))
+
+	expect(t, injector.Get(stringer, "").IsValid(), false)
+
+	injector.Register(&Greeter{"Jeremy"}, "")
+	expect(t, injector.Get(stringer, "").IsValid(), true)
+
+	injector.Register(&Farewell{"Jeremy"}, "")
+	expect(t, injector.Get(stringer, "").IsValid(), false)
+
+	injector.Unregister(reflect.TypeOf(&Greeter{}), "")
+	expect(t, injector.Get(stringer, "").Interface().(fmt.Stringer).String(), "Goodbye, Jeremy")
+
+	injector.Clear()
+	expect(t, injector.Get(stringer, "").IsValid(), false)
+}
+
+func BenchmarkGetInterface(b *testing.B) {
+	injector := zinject.New()
+	for i := 0; i < 100; i++ {
+		injector.Register(i, fmt.Sprint(i))
+	}
+	injector.Register(&Greeter{"Jeremy"}, "")
+	stringer := zinject.InterfaceOf((*fmt.Stringer)(nil))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !injector.Get(stringer, "").IsValid() {
+			b.Fatal("stringer not found")
+		}
+	}
+}
+
+func Test_InjectorRegisterKeys(t *testing.T) {
+	injector := zinject.New()
+	g := &Greeter{"Jeremy"}
+	injector.RegisterKeys(g, "", "default", "greeter")
+
+	typ := reflect.TypeOf(g)
+	expect(t, fmt.Sprint(injector.Keys(typ)), "[ default greeter]")
+	for _, key := range []string{"", "default", "greeter"} {
+		expect(t, injector.Get(typ, key).Interface(), g)
+	}
+
+	injector.SetKeys(reflect.TypeOf("string"), reflect.ValueOf("a dep"), "a", "b")
+	expect(t, len(injector.Registrations()), 5)
+	expect(t, injector.Get(reflect.TypeOf("string"), "b").String(), "a dep")
+}
+
+func TestInjectImplementorsPointerReceiver(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(Greeter{"Jeremy"}, "")
+
+	s := StringerStruct{}
+	err := injector.Inject(&s)
+	var pe *zinject.PointerReceiverError
+	expect(t, errors.As(err, &pe), true)
+	expect(t, pe.Value, reflect.TypeOf(Greeter{}))
+	expect(t, err.Error(), "zinject_test.Greeter does not implement fmt.Stringer (methods have pointer receivers), register a *zinject_test.Greeter instead")
+
+	// values mapped by Provide are addressable
+	injector = zinject.New()
+	zinject.Provide(injector, Greeter{"Jeremy"}, "")
+
+	err = injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Dep.String(), "Hello, My name isJeremy")
+}
+
+func Test_InjectorSnapshot(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "").Register(11, "")
+
+	restore := injector.Snapshot()
+	for i := 0; i < 2; i++ {
+		injector.Register("a mock", "").Register(&Greeter{"Mock"}, "")
+		injector.Unregister(reflect.TypeOf(11), "")
+		expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "a mock")
+
+		restore()
+		expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "a dep")
+		expect(t, injector.Get(reflect.TypeOf(11), "").IsValid(), true)
+		expect(t, injector.Get(reflect.TypeOf(&Greeter{}), "").IsValid(), false)
+		expect(t, fmt.Sprint(injector.Registrations()), "[{string } {int }]")
+	}
+}
+
+func Test_InjectorSnapshotProviders(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(&Database{DSN: "mysql://"}, "")
+	injector.ProvideConstructor(func(db *Database) *Repository { return &Repository{DB: db} }, "")
+
+	restore := injector.Snapshot()
+	injector.Register(&Database{DSN: "mock://"}, "")
+	repo, _ := zinject.Get[*Repository](injector, "")
+	expect(t, repo.DB.DSN, "mock://")
+
+	restore()
+	repo, _ = zinject.Get[*Repository](injector, "")
+	expect(t, repo.DB.DSN, "mysql://")
+}
+
+func Test_InjectorInjectValue(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "").RegisterAs("another dep", "", (*SpecialString)(nil))
+
+	s := TestStruct{Dep3: "kept"}
+	v, err := injector.InjectValue(s)
+	expect(t, err, nil)
+	expect(t, s.Dep1, "")
+
+	c := v.(TestStruct)
+	expect(t, c.Dep1, "a dep")
+	expect(t, c.Dep2, "another dep")
+	expect(t, c.Dep3, "kept")
+
+	v, err = injector.InjectValue(&s)
+	expect(t, err, nil)
+	expect(t, s.Dep1, "")
+	expect(t, v.(TestStruct).Dep1, "a dep")
+
+	_, err = injector.InjectValue(11)
+	refute(t, err, nil)
+
+	_, err = zinject.New().InjectValue(s)
+	refute(t, err, nil)
+}
+
+type EmbeddedBase struct {
+	Dep string `inject:""`
+}
+
+type embeddedLower struct {
+	Number int `inject:""`
+}
+
+type EmbeddedUntagged struct {
+	Untouched string
+}
+
+type EmbeddingStruct struct {
+	EmbeddedBase
+	*Greeter `inject:""`
+	embeddedLower
+	Own int `inject:""`
+}
+
+type EmbeddingPtrStruct struct {
+	*EmbeddedBase
+	*EmbeddedUntagged
+}
+
+type RecursiveEmbed struct {
+	*RecursiveEmbed
+	Dep string `inject:""`
+}
+
+func Test_InjectorEmbedded(t *testing.T) {
+	injector := zinject.New()
+	g := &Greeter{"Jeremy"}
+	injector.Register("a dep", "").Register(11, "").Register(g, "")
+
+	s := EmbeddingStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Dep, "a dep")
+	expect(t, s.Greeter, g)
+	expect(t, s.Number, 11)
+	expect(t, s.Own, 11)
+}
+
+func Test_InjectorEmbeddedPointer(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+
+	s := EmbeddingPtrStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	refute(t, s.EmbeddedBase, (*EmbeddedBase)(nil))
+	expect(t, s.Dep, "a dep")
+	expect(t, s.EmbeddedUntagged, (*EmbeddedUntagged)(nil))
+
+	base := &EmbeddedBase{}
+	s = EmbeddingPtrStruct{EmbeddedBase: base}
+	err = injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.EmbeddedBase, base)
+	expect(t, base.Dep, "a dep")
+
+	r := RecursiveEmbed{}
+	r.RecursiveEmbed = &r
+	err = injector.Inject(&r)
+	expect(t, err, nil)
+	expect(t, r.Dep, "a dep")
+}
+
+func Test_InjectorEmbeddedRecursive(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+
+	r := RecursiveEmbed{}
+	err := injector.Inject(&r)
+	expect(t, err, nil)
+	expect(t, r.Dep, "a dep")
+	expect(t, r.RecursiveEmbed.Dep, "a dep")
+	expect(t, r.RecursiveEmbed.RecursiveEmbed, (*RecursiveEmbed)(nil))
+}
+
+func Test_InjectorDefaultKey(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("shared dep", "")
+
+	injector.SetDefaultKey("auth")
+	injector.Register("auth dep", "").RegisterAs("auth special", "", (*SpecialString)(nil))
+
+	expect(t, fmt.Sprint(injector.Keys(reflect.TypeOf("string"))), "[ auth]")
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "auth dep")
+	expect(t, injector.Has(reflect.TypeOf("string"), ""), true)
+
+	_, err := injector.GetE(reflect.TypeOf(11), "")
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+	expect(t, nf.Key, "auth")
+
+	s := TestStruct{}
+	err = injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Dep1, "auth dep")
+	expect(t, s.Dep2, "auth special")
+
+	n := NamedStruct{}
+	injector.Register("primary dep", "primary").Register("replica dep", "replica")
+	err = injector.Inject(&n)
+	expect(t, err, nil)
+	expect(t, n.Primary, "primary dep")
+	expect(t, n.Default, "auth dep")
+
+	child := injector.Child()
+	expect(t, child.Get(reflect.TypeOf("string"), "").String(), "auth dep")
+
+	expect(t, injector.Unregister(reflect.TypeOf("string"), ""), true)
+	expect(t, injector.Get(reflect.TypeOf("string"), "").IsValid(), false)
+
+	injector.SetDefaultKey("")
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "shared dep")
+}
+
+func Test_InterfaceOfE(t *testing.T) {
+	iType, err := zinject.InterfaceOfE((*SpecialString)(nil))
+	expect(t, err, nil)
+	expect(t, iType.Kind(), reflect.Interface)
+
+	iType, err = zinject.InterfaceOfE((**fmt.Stringer)(nil))
+	expect(t, err, nil)
+	expect(t, iType, reflect.TypeOf((*fmt.Stringer)(nil)).Elem())
+
+	for _, v := range []interface{}{(*testing.T)(nil), nil, "string"} {
+		iType, err = zinject.InterfaceOfE(v)
+		refute(t, err, nil)
+		expect(t, iType, nil)
+	}
+}
+
+type Handler func(string) string
+
+type FuncStruct struct {
+	Greet   func(string) string `inject:""`
+	Handler Handler             `inject:""`
+	Plain   func(string) string `inject:"plain"`
+}
+
+func Test_InjectorFuncDependencies(t *testing.T) {
+	injector := zinject.New()
+	greet := func(name string) string { return "Hello " + name }
+	injector.Register(greet, "").
+		Register(Handler(func(name string) string { return "Handled " + name }), "").
+		Register(Handler(func(name string) string { return "Plain " + name }), "plain")
+
+	expect(t, injector.Get(reflect.TypeOf(greet), "").IsValid(), true)
+	expect(t, injector.Get(reflect.TypeOf(Handler(nil)), "").IsValid(), true)
+
+	s := FuncStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Greet("Jeremy"), "Hello Jeremy")
+	expect(t, s.Handler("Jeremy"), "Handled Jeremy")
+	// a named func type is assignable to its unnamed underlying type
+	expect(t, s.Plain("Jeremy"), "Plain Jeremy")
+
+	result, err := injector.Invoke(func(h Handler, g func(string) string) string {
+		return h(g("Jeremy"))
+	})
+	expect(t, err, nil)
+	expect(t, result[0].String(), "Handled Hello Jeremy")
+
+	h, ok := zinject.Get[Handler](injector, "plain")
+	expect(t, ok, true)
+	expect(t, h("Jeremy"), "Plain Jeremy")
+}
+
+func Test_NewWith(t *testing.T) {
+	injector := zinject.NewWith(
+		zinject.Pair{Value: "primary dep", Key: "primary"},
+		zinject.Pair{Value: "replica dep", Key: "replica"},
+		zinject.Pair{Value: "default dep"},
+	)
+
+	s := NamedStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Primary, "primary dep")
+	expect(t, s.Replica, "replica dep")
+	expect(t, s.Default, "default dep")
+
+	expect(t, len(zinject.NewWith().Registrations()), 0)
+}
+
+func Test_InjectorInjectInvalidTarget(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(1, "")
+
+	n := 0
+	err := injector.Inject(&n)
+	expect(t, err.Error(), "inject: target must be a struct or pointer to struct, got *int")
+	expect(t, n, 0)
+
+	err = injector.Inject(nil)
+	expect(t, err.Error(), "inject: target must be a struct or pointer to struct, got <nil>")
+
+	var s *TestStruct
+	err = injector.Inject(s)
+	expect(t, err.Error(), "inject: target must not be a nil pointer, got *zinject_test.TestStruct")
+
+	err = injector.InjectDeep(&s)
+	expect(t, err.Error(), "inject: target must not be a nil pointer, got *zinject_test.TestStruct")
+}
+
+func Test_InjectorRegisterDefault(t *testing.T) {
+	injector := zinject.New()
+
+	// the application registers first, the library default is ignored
+	injector.Register("override", "")
+	injector.RegisterDefault("default", "")
+	expect(t, injector.Get(reflect.TypeOf("string"), "").String(), "override")
+
+	injector.RegisterDefault(8080, "port")
+	expect(t, injector.Get(reflect.TypeOf(11), "port").Int(), int64(8080))
+	injector.RegisterDefault(9090, "port")
+	expect(t, injector.Get(reflect.TypeOf(11), "port").Int(), int64(8080))
+
+	// providers count as mappings
+	injector.Factory(func() float64 { return 1.5 }, "")
+	injector.RegisterDefault(2.5, "")
+	expect(t, injector.Get(reflect.TypeOf(1.0), "").Float(), 1.5)
+
+	// mappings of the parent do not prevent a local default
+	child := injector.Child()
+	child.RegisterDefault("child", "")
+	expect(t, child.Get(reflect.TypeOf("string"), "").String(), "child")
+}
+
+func Test_InjectorInvokeE(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("Jeremy", "")
+
+	val, err := injector.InvokeE(func(name string) (*Greeter, error) {
+		return &Greeter{name}, nil
+	})
+	expect(t, err, nil)
+	expect(t, val.(*Greeter).Name, "Jeremy")
+
+	val, err = injector.InvokeE(func(name string) error {
+		return errors.New("failed " + name)
+	})
+	expect(t, val, nil)
+	expect(t, err.Error(), "failed Jeremy")
+
+	val, err = injector.InvokeE(func(string) error { return nil })
+	expect(t, val, nil)
+	expect(t, err, nil)
+
+	_, err = injector.InvokeE(func(int) error { return nil })
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+
+	_, err = injector.InvokeE(func() string { return "" })
+	expect(t, err.Error(), "Cannot invoke function of type func() string: it must return (T, error) or error")
+
+	_, err = injector.InvokeE("not a func")
+	expect(t, err.Error(), "Cannot invoke non-function value of type string")
+}
+
+func Test_InjectorRegisterType(t *testing.T) {
+	injector := zinject.New()
+	stringerType := reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+	injector.RegisterType(stringerType, &Greeter{"Jeremy"}, "")
+	expect(t, injector.Get(stringerType, "").Interface().(fmt.Stringer).String(), "Hello, My name isJeremy")
+	expect(t, injector.Has(reflect.TypeOf(&Greeter{}), ""), false)
+
+	injector.RegisterType(reflect.TypeOf(int64(0)), int64(8), "")
+	expect(t, injector.Get(reflect.TypeOf(int64(0)), "").Int(), int64(8))
+
+	// nil is mapped as the nil value of the type
+	injector.RegisterType(stringerType, nil, "nil")
+	val := injector.Get(stringerType, "nil")
+	expect(t, val.IsValid(), true)
+	expect(t, val.IsNil(), true)
+
+	defer func() {
+		expect(t, fmt.Sprint(recover()), "Called inject.Set with a reflect.Value of type int not assignable to type int64")
+	}()
+	injector.RegisterType(reflect.TypeOf(int64(0)), 8, "")
+}
+
+func Test_InjectorInjectAll(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+
+	s := TestStruct{}
+	structs := []TestStruct{{}, {}}
+	pointers := []*TestStruct{{}, {}}
+	array := [2]TestStruct{}
+	err := injector.InjectAll(&s, structs, pointers, &array)
+	expect(t, err, nil)
+	expect(t, s.Dep1, "a dep")
+	expect(t, structs[1].Dep1, "a dep")
+	expect(t, pointers[1].Dep1, "a dep")
+	expect(t, array[1].Dep1, "a dep")
+
+	// every value is injected, errors are reported together
+	named := []NamedStruct{{}, {}}
+	s = TestStruct{}
+	err = injector.InjectAll(named, &s, 1)
+	expect(t, s.Dep1, "a dep")
+	expect(t, err.Error(), strings.Join([]string{
+		`inject: NamedStruct.Primary (key="primary"): no value for type string (argument 0, element 0)`,
+		`inject: NamedStruct.Primary (key="primary"): no value for type string (argument 0, element 1)`,
+		`inject: target must be a struct or pointer to struct, got int (argument 2)`,
+	}, "\n"))
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+}
+
+func BenchmarkRegister(b *testing.B) {
+	injector := zinject.New()
+	dep, g := strings.Repeat("a", 8), &Greeter{"Jeremy"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		injector.Register(dep, "")
+		injector.Register(g, "")
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	injector := zinject.New()
+	stringType, greeterType := reflect.TypeOf(""), reflect.TypeOf(&Greeter{})
+	dep, g := reflect.ValueOf(strings.Repeat("a", 8)), reflect.ValueOf(&Greeter{"Jeremy"})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		injector.Set(stringType, "", dep)
+		injector.Set(greeterType, "", g)
+	}
+}
+
+type WireStruct struct {
+	Dep   string `wire:""`
+	Other string `inject:"other" wire:"other,optional"`
+	Name  string `inject:""`
+}
+
+func Test_InjectorSetTagName(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+	injector.SetTagName("wire")
+
+	s := WireStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Dep, "a dep")
+	expect(t, s.Other, "")
+	expect(t, s.Name, "")
+
+	// the tag name is read per injector
+	s = WireStruct{}
+	err = zinject.New().Register("another dep", "").Inject(&s)
+	refute(t, err, nil)
+	err = zinject.New().Register("another dep", "").Register("other dep", "other").Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Dep, "")
+	expect(t, s.Name, "another dep")
+
+	child := injector.Child()
+	s = WireStruct{}
+	err = child.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Dep, "a dep")
+
+	injector.SetTagName("")
+	s = WireStruct{}
+	err = injector.Inject(&s)
+	refute(t, err, nil)
+}
+
+type PointerAdaptStruct struct {
+	Greeter *Greeter `inject:""`
+	Name    string   `inject:"name"`
+}
+
+func Test_InjectorPointerAdapt(t *testing.T) {
+	injector := zinject.New()
+	g := Greeter{"Jeremy"}
+	injector.Register(g, "")
+	name := "Jeremy"
+	injector.Register(&name, "name")
+
+	s := PointerAdaptStruct{}
+	err := injector.Inject(&s)
+	refute(t, err, nil)
+
+	injector.SetPointerAdapt(true)
+	err = injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Greeter.Name, "Jeremy")
+	expect(t, s.Name, "Jeremy")
+
+	// the field points to a copy of the mapped value
+	s.Greeter.Name = "changed"
+	expect(t, injector.Get(reflect.TypeOf(g), "").Interface().(Greeter).Name, "Jeremy")
+
+	// nil pointers are not dereferenced
+	child := injector.Child()
+	child.Register((*string)(nil), "name")
+	err = child.Inject(&PointerAdaptStruct{})
+	refute(t, err, nil)
+}
+
+type SelfStruct struct {
+	Inj zinject.Injector `inject:""`
+	Dep string           `inject:""`
+}
+
+func Test_InjectorSelf(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+
+	s := SelfStruct{}
+	err := injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Inj, injector)
+
+	// children resolve to themselves, not to their parent
+	child := injector.Child()
+	err = child.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Inj, child)
+
+	_, err = child.Invoke(func(inj zinject.Injector) {
+		expect(t, inj, child)
+	})
+	expect(t, err, nil)
+	expect(t, injector.Get(reflect.TypeOf((*zinject.Injector)(nil)).Elem(), "other").IsValid(), false)
+
+	// an explicit mapping wins
+	other := zinject.New()
+	zinject.Provide[zinject.Injector](injector, other, "")
+	err = child.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Inj, other)
+}
+
+type ThirdPartyStruct struct {
+	Primary string
+	Replica string
+	Port    int
+	Tagged  string `inject:"tagged"`
+	Missing float64
+	private string
+}
+
+func Test_InjectorInjectByName(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("primary dep", "primary").Register("default dep", "").
+		Register("tagged dep", "tagged").Register(8080, "port").Register("private dep", "private")
+
+	s := ThirdPartyStruct{Missing: 1.5}
+	err := injector.InjectByName(&s)
+	expect(t, err, nil)
+	expect(t, s.Primary, "primary dep")
+	expect(t, s.Replica, "default dep")
+	expect(t, s.Port, 8080)
+	expect(t, s.Tagged, "tagged dep")
+	expect(t, s.Missing, 1.5)
+	expect(t, s.private, "")
+
+	// tag only by default
+	s = ThirdPartyStruct{}
+	err = injector.Inject(&s)
+	expect(t, err, nil)
+	expect(t, s.Primary, "")
+	expect(t, s.Tagged, "tagged dep")
+}
+
+// BenchmarkGetMissing probes unmapped types, for which lookups must neither
+// create buckets nor allocate.
+func BenchmarkGetMissing(b *testing.B) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+	types := make([]reflect.Type, 1<<12)
+	for i := range types {
+		types[i] = reflect.ArrayOf(i, reflect.TypeOf(0))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if injector.Get(types[i%len(types)], "").IsValid() {
+			b.Fatal("unexpected value")
+		}
+	}
+}
+
+func Test_InjectorGetMissingNoBuckets(t *testing.T) {
+	parent := zinject.New()
+	injector := parent.Child()
+	injector.Register("a dep", "")
+	expect(t, zinject.Buckets(injector), 1)
+
+	for i := 0; i < 100; i++ {
+		typ := reflect.ArrayOf(i, reflect.TypeOf(0))
+		expect(t, injector.Get(typ, "").IsValid(), false)
+		expect(t, injector.Has(typ, "key"), false)
+		_, err := injector.GetE(typ, "")
+		refute(t, err, nil)
+	}
+	expect(t, injector.Get(reflect.TypeOf("string"), "missing").IsValid(), false)
+
+	expect(t, zinject.Buckets(injector), 1)
+	expect(t, zinject.Buckets(parent), 0)
+}
+
+type ManyDepsStruct struct {
+	Name    string  `inject:"name"`
+	Port    int     `inject:"port"`
+	Host    string  `inject:"host"`
+	Ratio   float64 `inject:"ratio"`
+	initted bool
+}
+
+func (s *ManyDepsStruct) Init() error {
+	s.initted = true
+	return nil
+}
+
+func Test_InjectorInjectAllErrors(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a name", "name").Register(1.5, "ratio")
+
+	s := ManyDepsStruct{}
+	err := injector.InjectAllErrors(&s)
+	expect(t, err.Error(), `inject: ManyDepsStruct.Port (key="port"): no value for type int
+inject: ManyDepsStruct.Host (key="host"): no value for type string`)
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+	expect(t, s.Name, "a name")
+	expect(t, s.Ratio, 1.5)
+	expect(t, s.initted, false)
+
+	// Inject stops at the first failure
+	s = ManyDepsStruct{}
+	err = injector.Inject(&s)
+	expect(t, err.Error(), `inject: ManyDepsStruct.Port (key="port"): no value for type int`)
+	expect(t, s.Ratio, 0.0)
+
+	// methods are reported too
+	err = injector.InjectAllErrors(&MethodStruct{})
+	expect(t, err.Error(), `inject: MethodStruct.Dep (key=""): no value for type string
+inject: MethodStruct.InjectCheck: Value not found for type string (argument 0)
+inject: MethodStruct.InjectName: Value not found for type string (argument 0)`)
+
+	injector.Register(8080, "port").Register("a host", "host")
+	s = ManyDepsStruct{}
+	err = injector.InjectAllErrors(&s)
+	expect(t, err, nil)
+	expect(t, s.initted, true)
+
+	err = injector.InjectAllErrors(nil)
+	refute(t, err, nil)
+}
+
+func Test_InjectorInvokeWith(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "").Register(11, "")
+
+	out, err := injector.InvokeWith(func(s string, n int, st fmt.Stringer) string {
+		return fmt.Sprint(s, n, st)
+	}, "request dep", &Greeter{"Jeremy"})
+	expect(t, err, nil)
+	expect(t, out[0].String(), "request dep11 Hello, My name isJeremy")
+
+	// overrides only apply to the call
+	out, err = injector.InvokeWith(func(s string) string { return s })
+	expect(t, err, nil)
+	expect(t, out[0].String(), "a dep")
+
+	_, err = injector.InvokeWith(func(s string) {}, 1.5)
+	expect(t, err.Error(), "Cannot invoke func(string) with an override of type float64, accepted by none of its arguments")
+
+	_, err = injector.InvokeWith(func(s string) {}, nil)
+	expect(t, err.Error(), "Cannot invoke func(string) with an override of type <nil>, accepted by none of its arguments")
+
+	_, err = injector.InvokeWith(func(st fmt.Stringer) {}, "a")
+	refute(t, err, nil)
+}
+
+func Test_InjectorSetBoth(t *testing.T) {
+	injector := zinject.New()
+	stringer := zinject.InterfaceOf((*fmt.Stringer)(nil))
+	g := &Greeter{"Jeremy"}
+
+	injector.SetBoth(stringer, "", reflect.ValueOf(g))
+	expect(t, injector.Get(stringer, "").Interface(), g)
+	expect(t, injector.Get(reflect.TypeOf(g), "").Interface(), g)
+	expect(t, len(injector.Registrations()), 2)
+
+	// a Value of the interface type itself is mapped by its dynamic type
+	var st fmt.Stringer = &Farewell{"Jeremy"}
+	injector.SetBoth(stringer, "farewell", reflect.ValueOf(&st).Elem())
+	expect(t, injector.Get(reflect.TypeOf(&Farewell{}), "farewell").Interface(), st)
+
+	// concrete types are only mapped once
+	injector.SetBoth(reflect.TypeOf(11), "", reflect.ValueOf(11))
+	expect(t, len(injector.Registrations()), 5)
+}
+
+func Test_InjectorSetObserver(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a dep", "")
+
+	var lookups []string
+	injector.SetObserver(func(typ reflect.Type, key string, found bool, d time.Duration) {
+		lookups = append(lookups, fmt.Sprintf("%v %q %v", typ, key, found))
+		expect(t, d >= 0, true)
+	})
+	injector.Get(reflect.TypeOf("string"), "")
+	injector.GetE(reflect.TypeOf(11), "missing")
+	injector.Child().Get(reflect.TypeOf("string"), "")
+	expect(t, strings.Join(lookups, ", "), `string "" true, int "missing" false, string "" true`)
+
+	injector.SetObserver(nil)
+	injector.Get(reflect.TypeOf("string"), "")
+	expect(t, len(lookups), 3)
+}
+
+func Test_InjectorCanInject(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("a name", "name").Register(1.5, "ratio")
+
+	s := ManyDepsStruct{}
+	err := injector.CanInject(&s)
+	expect(t, err.Error(), `inject: ManyDepsStruct.Port (key="port"): no value for type int
+inject: ManyDepsStruct.Host (key="host"): no value for type string`)
+	expect(t, s.Name, "")
+
+	injector.Register(8080, "port").Register("a host", "host")
+	err = injector.CanInject(&s)
+	expect(t, err, nil)
+	expect(t, s, ManyDepsStruct{})
+
+	// methods are checked but not called
+	m := MethodStruct{}
+	err = injector.CanInject(&m)
+	expect(t, err.Error(), `inject: MethodStruct.Dep (key=""): no value for type string
+inject: MethodStruct.InjectCheck: Value not found for type string (argument 0)
+inject: MethodStruct.InjectName: Value not found for type string (argument 0)`)
+	injector.Register("a dep", "").Register(11, "")
+	err = injector.CanInject(&m)
+	expect(t, err, nil)
+	expect(t, m.Injected(), false)
+
+	// nil embedded pointers are not allocated
+	e := EmbeddingPtrStruct{}
+	err = injector.CanInject(&e)
+	expect(t, err, nil)
+	expect(t, e.EmbeddedBase == nil, true)
+	err = zinject.New().CanInject(&e)
+	refute(t, err, nil)
+
+	err = injector.CanInject(1)
+	refute(t, err, nil)
+}
+
+func Test_InjectorCanInjectProviders(t *testing.T) {
+	injector := zinject.New()
+	calls := 0
+	injector.ProvideConstructor(func(n int) *Greeter {
+		calls++
+		return &Greeter{"Jeremy"}
+	}, "")
+
+	s := StringerStruct{}
+	err := injector.CanInject(&s)
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+
+	injector.Register(11, "")
+	expect(t, injector.CanInject(&s), nil)
+	expect(t, calls, 0)
+	expect(t, injector.Inject(&s), nil)
+	expect(t, calls, 1)
+}
+
+type JSONConfig struct {
+	Host    string `inject:"" json:"host,omitempty"`
+	Port    int    `inject:"" json:"port"`
+	Name    string `inject:""`
+	Ignored string `inject:"" json:"-"`
+	Keyed   string `inject:"keyed" json:"other"`
+}
+
+func Test_InjectorSetKeyFromTag(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("localhost", "host").Register(8080, "port").
+		Register("default", "").Register("keyed dep", "keyed").Register("other dep", "other")
+
+	c := JSONConfig{}
+	err := injector.Inject(&c)
+	refute(t, err, nil)
+
+	injector.SetKeyFromTag("json")
+	err = injector.Child().Inject(&c)
+	expect(t, err, nil)
+	expect(t, c.Host, "localhost")
+	expect(t, c.Port, 8080)
+	expect(t, c.Name, "default")
+	expect(t, c.Ignored, "default")
+	expect(t, c.Keyed, "keyed dep")
+
+	injector.SetKeyFromTag("")
+	c = JSONConfig{}
+	err = injector.Inject(&c)
+	refute(t, err, nil)
+}
+
+func Test_InjectorSetMap(t *testing.T) {
+	injector := zinject.New()
+	m := map[reflect.Type]reflect.Value{
+		reflect.TypeOf(""):         reflect.ValueOf("a dep"),
+		reflect.TypeOf(0):          reflect.ValueOf(11),
+		reflect.TypeOf(&Greeter{}): reflect.ValueOf(&Greeter{"Jeremy"}),
+		reflect.TypeOf(0.0):        {},
+	}
+
+	injector.SetMap(m, "generated", true)
+	expect(t, injector.Get(reflect.TypeOf(""), "generated").String(), "a dep")
+	expect(t, injector.Get(reflect.TypeOf(0), "generated").Int(), int64(11))
+	expect(t, injector.Has(reflect.TypeOf(0.0), "generated"), false)
+	expect(t, fmt.Sprint(injector.Registrations()), "[{*zinject_test.Greeter generated} {int generated} {string generated}]")
+
+	defer func() {
+		expect(t, fmt.Sprint(recover()), "Called inject.Set with an invalid reflect.Value for type float64")
+		expect(t, injector.Has(reflect.TypeOf(""), "other"), false)
+	}()
+	injector.SetMap(m, "other", false)
+}
+
+func Test_InjectorLen(t *testing.T) {
+	parent := zinject.New()
+	parent.Register("parent dep", "").Register(11, "")
+
+	injector := parent.Child()
+	expect(t, injector.Len(), 0)
+	expect(t, injector.IsEmpty(), true)
+	expect(t, injector.TotalLen(), 2)
+
+	injector.RegisterKeys("a dep", "", "other")
+	injector.Factory(func() *Greeter { return &Greeter{"Jeremy"} }, "")
+	expect(t, injector.Len(), 3)
+	expect(t, injector.IsEmpty(), false)
+	expect(t, injector.TotalLen(), 5)
+
+	injector.Clear()
+	expect(t, injector.IsEmpty(), true)
+	expect(t, parent.Len(), 2)
+}
+
+type Prioritized struct {
+	A string `inject:"a"`
+	B string `inject:"b,priority=10"`
+	C string `inject:"c,priority=10"`
+	D string `inject:"d,priority=-1"`
+	E string `inject:"e"`
+	F string `inject:"f,priority=5"`
+}
+
+func Test_InjectorPriority(t *testing.T) {
+	injector := zinject.New()
+	var order []string
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		key := key
+		injector.Factory(func() string {
+			order = append(order, key)
+			return key + " dep"
+		}, key)
+	}
+
+	p := Prioritized{}
+	expect(t, injector.Inject(&p), nil)
+	expect(t, strings.Join(order, ","), "b,c,f,a,e,d")
+	expect(t, p.A, "a dep")
+	expect(t, p.D, "d dep")
+}
+
+type Endpoint struct {
+	Name string `inject:"name"`
+	Port int    `inject:""`
+}
+
+func Test_InjectorGetAndInject(t *testing.T) {
+	injector := zinject.New()
+	injector.Register("api", "name").Register(8080, "")
+	injector.Factory(func() *Endpoint { return &Endpoint{} }, "")
+	injector.Register(Endpoint{Name: "stale"}, "value")
+
+	v, err := injector.GetAndInject(reflect.TypeOf(&Endpoint{}), "")
+	expect(t, err, nil)
+	s := v.Interface().(*Endpoint)
+	expect(t, s.Name, "api")
+	expect(t, s.Port, 8080)
+	expect(t, injector.Get(reflect.TypeOf(&Endpoint{}), "").Interface(), s)
+
+	v, err = injector.GetAndInject(reflect.TypeOf(Endpoint{}), "value")
+	expect(t, err, nil)
+	expect(t, v.Interface().(Endpoint).Name, "api")
+	expect(t, injector.Get(reflect.TypeOf(Endpoint{}), "value").Interface().(Endpoint).Name, "stale")
+
+	v, err = injector.GetAndInject(reflect.TypeOf(11), "")
+	expect(t, err, nil)
+	expect(t, v.Int(), int64(8080))
+
+	_, err = injector.GetAndInject(reflect.TypeOf(&Endpoint{}), "missing")
+	var nf *zinject.NotFoundError
+	expect(t, errors.As(err, &nf), true)
+
+	injector.Unregister(reflect.TypeOf(11), "")
+	_, err = injector.GetAndInject(reflect.TypeOf(&Endpoint{}), "")
+	refute(t, err, nil)
+}
+
+type EmbeddedInterfaceStruct struct {
+	fmt.Stringer `inject:""`
+	Name         string `inject:""`
+}
+
+type UntaggedEmbeddedInterfaceStruct struct {
+	fmt.Stringer
+	Child *EmbeddedInterfaceStruct `inject:"child"`
+}
+
+func Test_InjectorEmbeddedInterface(t *testing.T) {
+	injector := zinject.New()
+	injector.Register(&Greeter{"Jeremy"}, "").Register("a dep", "")
+
+	s := EmbeddedInterfaceStruct{}
+	expect(t, injector.Inject(&s), nil)
+	expect(t, s.String(), "Hello, My name isJeremy")
+	expect(t, s.Name, "a dep")
+
+	// the untagged embedded interface is left alone, and the value of an
+	// interface is not walked even deeply
+	greeter := &Greeter{"Jeremy"}
+	u := UntaggedEmbeddedInterfaceStruct{Stringer: greeter}
+	// a struct embedding an interface implements it through promotion
+	injector.Register(&EmbeddedInterfaceStruct{}, "child")
+	expect(t, injector.Get(zinject.InterfaceOf((*fmt.Stringer)(nil)), "child").IsValid(), true)
+	expect(t, injector.InjectDeep(&u), nil)
+	expect(t, u.Stringer, fmt.Stringer(greeter))
+	expect(t, u.Child.Name, "a dep")
+
+	u = UntaggedEmbeddedInterfaceStruct{}
+	expect(t, injector.InjectDeep(&u), nil)
+	expect(t, u.Stringer, nil)
+}
+
+type NilStruct struct {
+	Logger fmt.Stringer `inject:"logger"`
+	Hooks  []string     `inject:"hooks"`
+}
+
+func Test_InjectorRegisterNil(t *testing.T) {
+	injector := zinject.New()
+	stringerType := zinject.InterfaceOf((*fmt.Stringer)(nil))
+
+	expect(t, injector.Get(stringerType, "logger").IsValid(), false)
+	injector.RegisterNil(stringerType, "logger").RegisterNil(reflect.TypeOf([]string{}), "hooks")
+	expect(t, injector.Has(stringerType, "logger"), true)
+	v := injector.Get(stringerType, "logger")
+	expect(t, v.IsValid(), true)
+	expect(t, v.IsNil(), true)
+
+	s := NilStruct{Logger: &Greeter{"Jeremy"}, Hooks: []string{"stale"}}
+	expect(t, injector.Inject(&s), nil)
+	expect(t, s.Logger, nil)
+	expect(t, s.Hooks == nil, true)
+
+	defer func() {
+		refute(t, recover(), nil)
+	}()
+	injector.RegisterNil(reflect.TypeOf(11), "")
+}
+
+func Test_InjectorLocate(t *testing.T) {
+	root := zinject.New()
+	root.Register("root dep", "").Register(&Greeter{"Jeremy"}, "")
+	child := root.Child()
+	child.Register("child dep", "")
+	grandchild := child.Child()
+
+	typ := reflect.TypeOf("string")
+	inj, ok := grandchild.Locate(typ, "")
+	expect(t, ok, true)
+	expect(t, inj, child)
+
+	inj, ok = grandchild.Locate(reflect.TypeOf(&Greeter{}), "")
+	expect(t, ok, true)
+	expect(t, inj, root)
+
+	// exact types only
+	_, ok = grandchild.Locate(zinject.InterfaceOf((*fmt.Stringer)(nil)), "")
+	expect(t, ok, false)
+	_, ok = grandchild.Locate(typ, "other")
+	expect(t, ok, false)
+
+	grandchild.SetDefaultKey("default")
+	child.Register("keyed dep", "default")
+	inj, ok = grandchild.Locate(typ, "")
+	expect(t, ok, true)
+	expect(t, inj, child)
+}
+
+type Named interface {
+	Name() string
+}
+
+type Greeting interface {
+	Named
+	fmt.Stringer
+}
+
+type Polite interface {
+	Greeting
+	Bow() string
+}
+
+type Butler struct {
+	name string
+}
+
+func (b *Butler) Name() string   { return b.name }
+func (b *Butler) String() string { return "Good evening, " + b.name }
+func (b *Butler) Bow() string    { return b.name + " bows" }
+
+type InterfaceSubsetStruct struct {
+	Named    Named        `inject:""`
+	Greeting Greeting     `inject:""`
+	Stringer fmt.Stringer `inject:""`
+	Polite   Polite       `inject:""`
+}
+
+func TestInjectImplementorsSubset(t *testing.T) {
+	parent := zinject.New()
+	parent.RegisterAs(&Butler{"Alfred"}, "", (*Polite)(nil))
+	injector := parent.Child()
+
+	s := InterfaceSubsetStruct{}
+	expect(t, injector.Inject(&s), nil)
+	expect(t, s.Named.Name(), "Alfred")
+	expect(t, s.Greeting.String(), "Good evening, Alfred")
+	expect(t, s.Stringer.String(), "Good evening, Alfred")
+	expect(t, s.Polite.Bow(), "Alfred bows")
+
+	// the interface and concrete mappings of a value are not ambiguous
+	butler := &Butler{"Jeeves"}
+	injector.SetBoth(zinject.InterfaceOf((*Greeting)(nil)), "", reflect.ValueOf(butler))
+	s = InterfaceSubsetStruct{}
+	expect(t, injector.Inject(&s), nil)
+	expect(t, s.Named.Name(), "Jeeves")
+	expect(t, s.Stringer.String(), "Good evening, Jeeves")
+	expect(t, s.Polite.Name(), "Jeeves")
+
+	// distinct values are
+	injector.Register(&Butler{"Other"}, "")
+	_, err := injector.GetE(zinject.InterfaceOf((*Named)(nil)), "")
+	var ae *zinject.AmbiguousError
+	expect(t, errors.As(err, &ae), true)
+	expect(t, len(ae.Candidates), 2)
+}
+
+type Holder interface {
+	Held() interface{}
+}
+
+type SliceHolder struct {
+	X interface{}
+}
+
+func (h SliceHolder) Held() interface{} { return h.X }
+
+func TestInjectImplementorsUncomparable(t *testing.T) {
+	injector := zinject.New()
+	holderType := zinject.InterfaceOf((*Holder)(nil))
+	injector.SetBoth(holderType, "", reflect.ValueOf(SliceHolder{[]int{1}}))
+	injector.Register(SliceHolder{[]int{1}}, "other")
+
+	expect(t, injector.Get(holderType, "").IsValid(), true)
+	// uncomparable values are never deemed the same
+	_, err := injector.GetE(zinject.InterfaceOf((*interface{ Held() interface{} })(nil)), "")
+	var ae *zinject.AmbiguousError
+	expect(t, errors.As(err, &ae), true)
+}